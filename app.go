@@ -10,17 +10,22 @@ package fiber
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v2/log"
@@ -62,6 +67,13 @@ type Storage interface {
 	Close() error
 }
 
+// Logger is the interface the underlying fasthttp server uses to report
+// server-level errors, e.g. failures while accepting connections. It is
+// satisfied by log.Logger from the standard library.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
 // ErrorHandler defines a function that will process all errors
 // returned from any handlers in the stack
 //
@@ -89,16 +101,50 @@ type App struct {
 	mutex sync.Mutex
 	// Route stack divided by HTTP methods
 	stack [][]*Route
-	// Route stack divided by HTTP methods and route prefixes
-	treeStack []map[string][]*Route
+	// Route stack divided by HTTP methods and route prefixes, holds a
+	// []map[string][]*Route that is swapped whole on rebuild so requests
+	// always read a consistent tree, see RebuildTreeAsync
+	treeStack atomic.Value
 	// contains the information if the route stack has been changed to build the optimized tree
 	routesRefreshed bool
+	// Duration of the last route tree rebuild, see LastTreeBuildDuration
+	treeBuildDuration time.Duration
+	// Subscribers notified whenever a route enters the stack, see OnRouteAdd
+	onRouteAdd []func(r *Route)
+	// Subscribers notified on request errors, see OnError
+	onError []func(c *Ctx, err error)
+	// Error handlers keyed by error type, see OnErrorType
+	errorTypeHandlers []errorTypeHandler
+	// Hooks run before route matching, see OnPreRoute
+	onPreRoute []func(c *Ctx)
+	// In-flight coalesced handler executions, see SingleFlight
+	flights flightGroup
+	// Whether any route opted out of global middleware, see SkipMiddleware
+	hasSkipMiddlewareRoutes bool
+	// Whether startup fails on ambiguous routes, see EnforceUnambiguousRoutes
+	enforceUnambiguousRoutes bool
+	// Maintenance mode state, holds a *maintenanceMode, see SetMaintenanceMode
+	maintenance atomic.Value
+	// Path rewrite rules applied before matching, see AddRewrite
+	rewrites []rewriteRule
+	// Optional metric label transformer, see SetMetricPathTransformer
+	metricPathTransformer func(route *Route, path string) string
+	// Last known state per client connection, used by connStateHook
+	connStates sync.Map
+	// Connection gauges and reap counter, see ConnMetrics
+	activeConns int64
+	idleConns   int64
+	reapedConns int64
+	// Responses currently being streamed, see Config.MaxConcurrentStreams
+	activeStreams int64
 	// Amount of registered routes
 	routesCount uint32
 	// Amount of registered handlers
 	handlersCount uint32
 	// Ctx pool
 	pool sync.Pool
+	// Scratch byte buffer pool for handlers, see Ctx.AcquireBuffer
+	bufferPool sync.Pool
 	// Fasthttp server
 	server *fasthttp.Server
 	// App config
@@ -115,6 +161,8 @@ type App struct {
 	tlsHandler *TLSHandler
 	// Mount fields
 	mountFields *mountFields
+	// Catch-all handler for unmatched requests
+	fallbackHandler Handler
 	// Indicates if the value was explicitly configured
 	configured Config
 }
@@ -126,17 +174,201 @@ type Config struct {
 	// Default: false
 	Prefork bool `json:"prefork"`
 
-	// Enables the "Server: value" HTTP header.
+	// Enables the "Server: value" HTTP header. When left empty the underlying
+	// fasthttp server announces its own default name; use DisableServerHeader
+	// to send no Server header at all.
 	//
 	// Default: ""
 	ServerHeader string `json:"server_header"`
 
+	// When set to true, no Server header is sent at all, regardless of
+	// ServerHeader.
+	//
+	// Default: false
+	DisableServerHeader bool `json:"disable_server_header"`
+
 	// When set to true, the router treats "/foo" and "/foo/" as different.
 	// By default this is disabled and both "/foo" and "/foo/" will execute the same handler.
 	//
 	// Default: false
 	StrictRouting bool `json:"strict_routing"`
 
+	// When set to true, strict routing is applied to all mutating methods
+	// (POST, PUT, DELETE, ...) while the safe methods GET and HEAD keep the
+	// lenient trailing-slash behavior. It has no effect when StrictRouting
+	// is enabled, which is strict for every method.
+	//
+	// Default: false
+	StrictForMutatingMethods bool `json:"strict_for_mutating_methods"`
+
+	// When set to true on an app that gets mounted into another app, its
+	// routes keep this app's CaseSensitive and StrictRouting semantics
+	// instead of adopting the parent's. Matching such routes bypasses the
+	// parent's path normalization, which costs a little performance.
+	//
+	// Default: false
+	PreserveRoutingConfigOnMount bool `json:"preserve_routing_config_on_mount"`
+
+	// When set to true, Ctx.Params returns captured route parameters verbatim
+	// instead of percent-decoding them, e.g. "/user/John%20Doe" yields
+	// "John%20Doe" rather than "John Doe". Encoded slashes within a param are
+	// only decoded, they never change which route matched.
+	//
+	// Default: false
+	DisableParamsDecoding bool `json:"disable_params_decoding"`
+
+	// When set to true, requesting an "https"-only route over plain http
+	// yields a 404 instead of the default 301 redirect to the https URL.
+	//
+	// Default: false
+	DisableSchemeRedirect bool `json:"disable_scheme_redirect"`
+
+	// When set to true, every request records which tree bucket the router
+	// selected and which routes were tested in which order, accessible via
+	// Ctx.MatchTrace. Meant for debugging unexpected 404s and handler
+	// ordering in complex route tables, not for production traffic.
+	//
+	// Default: false
+	EnableMatchTrace bool `json:"enable_match_trace"`
+
+	// When set to true, OPTIONS requests that match no registered OPTIONS
+	// route are answered automatically with 204 No Content and an Allow
+	// header listing the methods registered for the path. The Allow set is
+	// computed via AllowedMethods, so param and wildcard routes contribute
+	// their method for any matching subpath.
+	//
+	// Default: false
+	EnableAutoOptions bool `json:"enable_auto_options"`
+
+	// When set to true, HEAD requests to paths that are registered only
+	// under other methods are answered with an empty 200 instead of a
+	// 405/404, confirming the resource's existence to probing proxies.
+	// This is independent of the HEAD routes that Get registers alongside
+	// every GET route - those match before this fallback is consulted.
+	//
+	// Default: false
+	EnableAutoHead bool `json:"enable_auto_head"`
+
+	// When set to true, wildcard routes no longer win just by being
+	// registered first: the route tree is reordered so routes whose path
+	// contains a "*" segment are tried after every other route, letting a
+	// more specific route registered later take priority. Middleware
+	// registered via Use is not demoted and keeps matching in
+	// registration order.
+	//
+	// Default: false
+	EnableWildcardDemotion bool `json:"enable_wildcard_demotion"`
+
+	// When set to true, every handler invocation is timed and the breakdown
+	// is exposed via Ctx.HandlerTimings, attributing latency to individual
+	// middlewares versus the terminal handler. Costs one time measurement
+	// per handler when enabled and nothing when disabled.
+	//
+	// Default: false
+	EnableHandlerTimings bool `json:"enable_handler_timings"`
+
+	// When set to true, every route's handler chain is folded into one
+	// composed function when the route tree is built. Requests then follow
+	// precompiled continuations through Ctx.Next instead of incrementing
+	// and bounds-checking the handler index per hop. Whether that pays off
+	// depends on chain length and workload, see Benchmark_Router_Chain_*.
+	// Next semantics including HandlerIndex are preserved.
+	//
+	// Default: false
+	EnableCompiledHandlerChains bool `json:"enable_compiled_handler_chains"`
+
+	// When set to true, middleware prefixes are matched as plain string
+	// prefixes like in earlier releases, so middleware registered at "/api"
+	// also runs for "/apiary". By default the prefix must end on a
+	// path-segment boundary.
+	//
+	// Default: false
+	EnableLooseMiddlewarePrefix bool `json:"enable_loose_middleware_prefix"`
+
+	// Maximum number of responses streamed concurrently via SendStream.
+	// Every streaming response holds a goroutine and a Ctx for its entire
+	// lifetime, so long-lived streams (SSE and the like) can exhaust memory
+	// unbounded. Beyond the limit SendStream rejects the request with 503
+	// Service Unavailable and a Retry-After header. 0 means unlimited.
+	//
+	// Default: 0
+	MaxConcurrentStreams int `json:"max_concurrent_streams"`
+
+	// UpgradeHandler is called instead of the route's handlers when a
+	// request asks for a protocol upgrade (Connection: Upgrade) on a route
+	// that was not marked upgrade-capable via AllowUpgrade. Return
+	// ErrUpgradeRequired or a 400 error to reject cleanly, the result is
+	// routed through ErrorHandler. When nil, upgrade requests run through
+	// the normal handler chain unchanged.
+	//
+	// Default: nil
+	UpgradeHandler func(c *Ctx) error `json:"-"`
+
+	// When set to true, Ctx.NormalizedHost keeps the port of the Host
+	// header, for setups where host matching is port-sensitive. The host is
+	// still lowercased and stripped of a trailing dot.
+	//
+	// Default: false
+	PreserveHostPort bool `json:"preserve_host_port"`
+
+	// PathParser replaces the built-in route pattern syntax with a custom
+	// one. The parser receives every registered pattern and returns its
+	// segments, see the PathParser interface. Constraints and escape
+	// sequences of the built-in syntax do not apply to custom parsers.
+	// When nil, the default Fiber syntax is used.
+	//
+	// Default: nil
+	PathParser PathParser `json:"-"`
+
+	// OnIdleConnReaped is called whenever a kept-alive connection is closed
+	// while sitting idle, which is what happens when IdleTimeout reaps it.
+	// Together with the ConnMetrics gauges this helps tune IdleTimeout
+	// against observed reap rates. The callback runs on the connection's
+	// goroutine and must not block.
+	//
+	// Default: nil
+	OnIdleConnReaped func(conn net.Conn) `json:"-"`
+
+	// RecoverHandler converts a panic raised in a handler into an error that
+	// is passed through ErrorHandler, so a single panic neither crashes the
+	// connection nor leaks the pooled Ctx. Return a *fiber.Error to control
+	// the status code, e.g.:
+	//
+	//	cfg.RecoverHandler = func(c *Ctx, recovered interface{}) error {
+	//	    return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("%v", recovered))
+	//	}
+	//
+	// When nil, panics propagate as before and only a recover middleware can
+	// catch them.
+	//
+	// Default: nil
+	RecoverHandler func(c *Ctx, recovered interface{}) error `json:"-"`
+
+	// When set to true, routes restricted with Consumes also reject requests
+	// that carry neither a body nor a Content-Type header. By default such
+	// requests pass, since there is no media type to judge.
+	//
+	// Default: false
+	ConsumesRequireContentType bool `json:"consumes_require_content_type"`
+
+	// Maximum number of handlers a single route may accumulate when repeated
+	// registrations on the same path are merged. Exceeding the limit panics
+	// at registration naming the path, which catches loops that keep
+	// appending middleware to one route. 0 means unlimited.
+	//
+	// Default: 0
+	MaxHandlersPerRoute int `json:"max_handlers_per_route"`
+
+	// When set to true, errors caused by the client disconnecting while the
+	// request was being read do not invoke the ErrorHandler at all, since
+	// nobody is left to read the response. By default such errors are passed
+	// to the ErrorHandler as ErrClientDisconnected. A handler error only
+	// counts as a disconnect when it wraps ErrClientDisconnected, which body
+	// reads through Ctx.BodyStream do automatically.
+	//
+	// Default: false
+	SkipErrorHandlerOnDisconnect bool `json:"skip_error_handler_on_disconnect"`
+
 	// When set to true, enables case sensitive routing.
 	// E.g. "/FoO" and "/foo" are treated as different routes.
 	// By default this is disabled and both "/FoO" and "/foo" will execute the same handler.
@@ -160,6 +392,96 @@ type Config struct {
 	// Default: false
 	UnescapePath bool `json:"unescape_path"`
 
+	// When set to true, percent-escapes of RFC 3986 unreserved characters
+	// are decoded in the detection path before routes are matched, so
+	// "/files/report%2Epdf" reaches the route registered as
+	// "/files/report.pdf". Escapes of reserved characters such as %2F stay
+	// raw, decoding never introduces new path separators. Ctx.Path() and
+	// captured parameter values keep the original escapes; apps that need
+	// fully decoded routing and params should use UnescapePath instead.
+	//
+	// Default: false, the escaped and literal forms are distinct paths
+	UnescapeDetectionPath bool `json:"unescape_detection_path"`
+
+	// When set to true, consecutive slashes in the request path are collapsed
+	// into a single one before route matching, so "/api//users" matches the
+	// route "/api/users". Collapsing can change path semantics behind
+	// proxies, which is why it is opt-in.
+	//
+	// Default: false
+	CollapseSlashes bool `json:"collapse_slashes"`
+
+	// Maximum number of '/'-separated segments allowed in a request path.
+	// Deeper paths are rejected with 414 Request URI Too Long before any
+	// matching work is done, protecting param extraction and wildcard
+	// matching from pathological inputs. 0 means unlimited.
+	//
+	// Default: 0
+	MaxPathSegments int `json:"max_path_segments"`
+
+	// Maximum number of response body bytes a handler may produce. Larger
+	// buffered responses are dropped, logged and replaced with 500 Internal
+	// Server Error before anything is sent. Streams set up via SendStream
+	// are aborted mid-transfer once the limit is crossed, closing the
+	// connection so the client never mistakes the truncated body for a
+	// complete response. 0 means unlimited.
+	//
+	// Default: 0
+	MaxResponseBodySize int `json:"max_response_body_size"`
+
+	// When set to true, the default responses for errors produced by the
+	// router itself - 404 Not Found and 405 Method Not Allowed - are
+	// negotiated from the request's Accept header: text/html for browsers,
+	// application/json for API clients and text/plain otherwise. Handler
+	// errors keep the plain-text default. The bodies can be customized per
+	// media type via ErrorTemplates.
+	//
+	// Default: false
+	NegotiateErrors bool `json:"negotiate_errors"`
+
+	// Bodies for negotiated error responses keyed by media type, used when
+	// NegotiateErrors is on. The placeholders %code% and %message% are
+	// replaced with the status code and error message. Missing entries fall
+	// back to built-in bodies for text/html and application/json.
+	//
+	// Default: nil
+	ErrorTemplates map[string]string `json:"-"`
+
+	// LogFormats holds named access log templates that routes reference via
+	// LogAs. A referenced template is rendered once per request after the
+	// response is finished; the placeholders %method%, %path%, %route%,
+	// %status% and %duration% are replaced with the request's values. No
+	// global logging middleware is involved, routes without LogAs pay
+	// nothing.
+	//
+	// Default: nil
+	LogFormats map[string]string `json:"-"`
+
+	// LogWriter receives the rendered per-route access log lines, one write
+	// per line.
+	//
+	// Default: os.Stdout
+	LogWriter io.Writer `json:"-"`
+
+	// TrailingSlashHandler is invoked when StrictRouting is on and a
+	// request missed solely because of its trailing slash, i.e. the path
+	// with the slash toggled does match a route for the method. The handler
+	// can redirect to the canonical form or answer directly. When nil, such
+	// misses stay plain 404s.
+	//
+	// Default: nil
+	TrailingSlashHandler Handler `json:"-"`
+
+	// HeaderLimitHandler is invoked when the server cannot read a request
+	// because its headers exceed the read buffer, before the general
+	// ErrorHandler is consulted. It can explain the limit to the client,
+	// e.g. suggest smaller headers or fewer cookies. When nil, such
+	// requests are answered through ErrorHandler with
+	// ErrRequestHeaderFieldsTooLarge.
+	//
+	// Default: nil
+	HeaderLimitHandler Handler `json:"-"`
+
 	// Enable or disable ETag header generation, since both weak and strong etags are generated
 	// using the same hashing method (CRC-32). Weak ETags are the default when enabled.
 	//
@@ -251,6 +573,19 @@ type Config struct {
 	// Default: DefaultErrorHandler
 	ErrorHandler ErrorHandler `json:"-"`
 
+	// Logger is used by the underlying fasthttp server to report server-level
+	// errors, so they surface to your logging stack instead of being discarded.
+	//
+	// Default: a logger that discards all output
+	Logger Logger `json:"-"`
+
+	// RateLimitStore holds the counters for per-route rate limiting
+	// registered via Route.RateLimit. Plug in a shared store (e.g. Redis)
+	// when running multiple instances.
+	//
+	// Default: an in-memory store
+	RateLimitStore RateLimitStore `json:"-"`
+
 	// When set to true, disables keep-alive connections.
 	// The server will close incoming connections after sending the first response to client.
 	//
@@ -286,6 +621,10 @@ type Config struct {
 	// StreamRequestBody enables request body streaming,
 	// and calls the handler sooner when given body is
 	// larger then the current limit.
+	//
+	// BodyLimit is still enforced as a hard cap: requests declaring a body
+	// larger than the limit are rejected with 413 before the handler runs
+	// instead of being streamed indefinitely.
 	StreamRequestBody bool
 
 	// Will not pre parse Multipart Form data if set to true.
@@ -381,6 +720,21 @@ type Config struct {
 	// Default: false
 	EnablePrintRoutes bool `json:"enable_print_routes"`
 
+	// If set to true, route registration also rejects paths containing
+	// spaces, catching copy-paste errors early. Control characters are
+	// always rejected, regardless of this setting. Intentionally escaped
+	// characters stay allowed.
+	//
+	// Default: false
+	EnableStrictPathValidation bool `json:"enable_strict_path_validation"`
+
+	// If set to true, registering a route with an empty path panics instead
+	// of silently falling back to "/". This catches accidentally empty path
+	// constants that would otherwise register a root handler.
+	//
+	// Default: false, an empty path registers "/"
+	RejectEmptyPath bool `json:"reject_empty_path"`
+
 	// You can define custom color scheme. They'll be used for startup message, route list and some middlewares.
 	//
 	// Optional. Default: DefaultColors
@@ -397,8 +751,30 @@ type Config struct {
 	//
 	// Optional. Default: false
 	EnableSplittingOnParsers bool `json:"enable_splitting_on_parsers"`
+
+	// EmptyResponseMode controls what happens when a matched handler chain
+	// returns nil without touching the response, which would otherwise go
+	// out as an empty 200. Note that a handler deliberately sending an
+	// empty 200 is indistinguishable from one that wrote nothing.
+	//
+	// Optional. Default: EmptyResponseDefault
+	EmptyResponseMode EmptyResponseMode `json:"empty_response_mode"`
 }
 
+// EmptyResponseMode decides how an untouched response left behind by a
+// handler that returned nil is answered, see Config.EmptyResponseMode.
+type EmptyResponseMode int
+
+const (
+	// EmptyResponseDefault keeps the current behavior, an empty 200
+	EmptyResponseDefault EmptyResponseMode = iota
+	// EmptyResponseNoContent rewrites the untouched response into a 204
+	EmptyResponseNoContent
+	// EmptyResponseError treats the untouched response as a programming
+	// error and answers with a 500 through the error handler
+	EmptyResponseError
+)
+
 // Static defines configuration options when defining static assets.
 type Static struct {
 	// When set to true, the server tries minimizing CPU usage by caching compressed files.
@@ -475,6 +851,34 @@ var DefaultMethods = []string{
 	MethodPatch,
 }
 
+// Validate reports the first inconsistent or invalid Config value, or nil when
+// the config is usable. Zero values are never invalid, they select the
+// documented defaults: a BodyLimit of 0 means DefaultBodyLimit (not unlimited),
+// a Concurrency of 0 means DefaultConcurrency, and zero timeouts mean
+// unlimited. A BodyLimit of -1 keeps its documented meaning of declining any
+// body. Other negative sizes and durations have no meaning and are rejected.
+// New calls Validate and panics on an error to surface misconfiguration at
+// construction time instead of at the first request.
+func (c Config) Validate() error {
+	switch {
+	case c.BodyLimit < -1:
+		return fmt.Errorf("config: BodyLimit must not be smaller than -1, leave it 0 for the default of %d bytes or set -1 to decline any body", DefaultBodyLimit)
+	case c.Concurrency < 0:
+		return fmt.Errorf("config: Concurrency must not be negative, leave it 0 for the default of %d", DefaultConcurrency)
+	case c.ReadBufferSize < 0:
+		return fmt.Errorf("config: ReadBufferSize must not be negative, leave it 0 for the default of %d bytes", DefaultReadBufferSize)
+	case c.WriteBufferSize < 0:
+		return fmt.Errorf("config: WriteBufferSize must not be negative, leave it 0 for the default of %d bytes", DefaultWriteBufferSize)
+	case c.ReadTimeout < 0:
+		return errors.New("config: ReadTimeout must not be negative, leave it 0 for no timeout")
+	case c.WriteTimeout < 0:
+		return errors.New("config: WriteTimeout must not be negative, leave it 0 for no timeout")
+	case c.IdleTimeout < 0:
+		return errors.New("config: IdleTimeout must not be negative, leave it 0 to fall back to ReadTimeout")
+	}
+	return nil
+}
+
 // DefaultErrorHandler that process return errors from handlers
 func DefaultErrorHandler(c *Ctx, err error) error {
 	code := StatusInternalServerError
@@ -482,6 +886,10 @@ func DefaultErrorHandler(c *Ctx, err error) error {
 	if errors.As(err, &e) {
 		code = e.Code
 	}
+	// Routing errors are negotiated from the Accept header when enabled
+	if c.app.config.NegotiateErrors && (code == StatusNotFound || code == StatusMethodNotAllowed) {
+		return negotiatedErrorResponse(c, code, err.Error())
+	}
 	c.Set(HeaderContentType, MIMETextPlainCharsetUTF8)
 	return c.Status(code).SendString(err.Error())
 }
@@ -526,6 +934,12 @@ func New(config ...Config) *App {
 	// Initialize configured before defaults are set
 	app.configured = app.config
 
+	// Reject configs that cannot be made sense of before defaults paper
+	// over them
+	if err := app.config.Validate(); err != nil {
+		panic(err)
+	}
+
 	if app.config.ETag {
 		if !IsChild() {
 			log.Warn("Config.ETag is deprecated since v2.0.6, please use 'middleware/etag'.")
@@ -568,6 +982,9 @@ func New(config ...Config) *App {
 	if app.config.Network == "" {
 		app.config.Network = NetworkTCP4
 	}
+	if app.config.RateLimitStore == nil {
+		app.config.RateLimitStore = newMemoryRateLimitStore()
+	}
 	if len(app.config.RequestMethods) == 0 {
 		app.config.RequestMethods = DefaultMethods
 	}
@@ -579,7 +996,7 @@ func New(config ...Config) *App {
 
 	// Create router stack
 	app.stack = make([][]*Route, len(app.config.RequestMethods))
-	app.treeStack = make([]map[string][]*Route, len(app.config.RequestMethods))
+	app.treeStack.Store(make([]map[string][]*Route, len(app.config.RequestMethods)))
 
 	// Override colors
 	app.config.ColorScheme = defaultColors(app.config.ColorScheme)
@@ -613,11 +1030,136 @@ func (app *App) SetTLSHandler(tlsHandler *TLSHandler) {
 	app.mutex.Unlock()
 }
 
-// Name Assign name to specific route.
+// SetMetricPathTransformer installs a function that maps a matched route and
+// the request path to the label used for metric aggregation, exposed via
+// Ctx.MetricPath. This allows stripping ID-like segments from legacy routes
+// that do not declare them as params. The transformer runs lazily when
+// MetricPath is called, not on every request.
+func (app *App) SetMetricPathTransformer(transformer func(route *Route, path string) string) {
+	app.mutex.Lock()
+	app.metricPathTransformer = transformer
+	app.mutex.Unlock()
+}
+
+// OnRouteAdd subscribes to route registration. The callback runs once for
+// every new route entering the route table - registered directly, propagated
+// from a mounted sub-app or imported when the sub-app's routes are pulled at
+// startup - at registration time, before the routing tree is (re)built.
+// Routes whose handlers are merged into an already registered route on the
+// same path do not fire again. The route must not be mutated.
+func (app *App) OnRouteAdd(callback func(r *Route)) {
+	app.mutex.Lock()
+	app.onRouteAdd = append(app.onRouteAdd, callback)
+	app.mutex.Unlock()
+}
+
+// notifyRouteAdd runs the OnRouteAdd subscribers for a route that was just
+// appended to the stack
+func (app *App) notifyRouteAdd(route *Route) {
+	for _, callback := range app.onRouteAdd {
+		callback(route)
+	}
+}
+
+// OnError subscribes to request errors, e.g. for reporting them to an error
+// tracking service. The callbacks run with the error a handler or the server
+// produced, before ErrorHandler writes the response, so reporters see the raw
+// error. They are notified once per request: a failure of ErrorHandler itself
+// is only logged, it does not trigger the callbacks again.
+func (app *App) OnError(callback func(c *Ctx, err error)) {
+	app.mutex.Lock()
+	app.onError = append(app.onError, callback)
+	app.mutex.Unlock()
+}
+
+// notifyError runs the OnError subscribers for an errored request
+func (app *App) notifyError(c *Ctx, err error) {
+	for _, callback := range app.onError {
+		callback(c, err)
+	}
+}
+
+// errorTypeHandler ties an error handler to the Go type it was registered
+// for via OnErrorType
+type errorTypeHandler struct {
+	typ     reflect.Type
+	handler ErrorHandler
+}
+
+// OnErrorType registers an error handler for the Go type of target, keeping
+// error handling modular instead of one ErrorHandler switching on types:
+//
+//	app.OnErrorType(&ValidationError{}, func(c *Ctx, err error) error {
+//		return c.Status(StatusUnprocessableEntity).SendString(err.Error())
+//	})
+//
+// When a handler returns an error, ErrorHandler consults the registry: an
+// error whose own type is registered wins over one found by unwrapping via
+// errors.As, ties fall back to registration order. Errors matching no
+// registered type keep going through the configured ErrorHandler.
+func (app *App) OnErrorType(target error, handler ErrorHandler) {
+	if target == nil || handler == nil {
+		panic("OnErrorType: nil target or handler\n")
+	}
+	app.mutex.Lock()
+	app.errorTypeHandlers = append(app.errorTypeHandlers, errorTypeHandler{
+		typ:     reflect.TypeOf(target),
+		handler: handler,
+	})
+	app.mutex.Unlock()
+}
+
+// errorHandlerFor resolves the type-keyed handler for err, nil when no
+// registered type matches. The error's own dynamic type beats a match found
+// by unwrapping, see OnErrorType.
+func (app *App) errorHandlerFor(err error) ErrorHandler {
+	if len(app.errorTypeHandlers) == 0 {
+		return nil
+	}
+	errType := reflect.TypeOf(err)
+	var unwrapped ErrorHandler
+	for _, entry := range app.errorTypeHandlers {
+		if entry.typ == errType {
+			return entry.handler
+		}
+		if unwrapped == nil && errors.As(err, reflect.New(entry.typ).Interface()) {
+			unwrapped = entry.handler
+		}
+	}
+	return unwrapped
+}
+
+// OnPreRoute registers a hook that runs for every request after the context
+// is set up but before route matching starts. The usual job of such a hook is
+// resolving request attributes via Ctx.SetAttr, e.g. the tenant from a token,
+// which routes then constrain on via Attr. Hooks run in registration order
+// and must not block, they are on the hot path of every request.
+func (app *App) OnPreRoute(callback func(c *Ctx)) {
+	app.mutex.Lock()
+	app.onPreRoute = append(app.onPreRoute, callback)
+	app.mutex.Unlock()
+}
+
+// Name Assign name to specific route. A name must be unique, it is the key
+// for URL generation; assigning a name another route already carries panics
+// at registration instead of breaking GetRoute silently.
 func (app *App) Name(name string) Router {
 	app.mutex.Lock()
 	defer app.mutex.Unlock()
 
+	// The final name of the latest route includes its group prefix
+	finalName := name
+	if app.latestRoute.group != nil {
+		finalName = app.latestRoute.group.name + name
+	}
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			if route.Name == finalName && route.Path != app.latestRoute.Path {
+				panic(fmt.Sprintf("name: duplicate route name %s for paths %s and %s\n", finalName, route.Path, app.latestRoute.Path))
+			}
+		}
+	}
+
 	for _, routes := range app.stack {
 		for _, route := range routes {
 			isMethodValid := route.Method == app.latestRoute.Method || app.latestRoute.use ||
@@ -639,80 +1181,1002 @@ func (app *App) Name(name string) Router {
 	return app
 }
 
-// GetRoute Get route by name
-func (app *App) GetRoute(name string) Route {
+// SkipMiddleware marks the latest registered route to run without any of
+// the middleware registered via Use, typically for internal health or
+// metrics endpoints that should not pay for auth or logging:
+//
+//	app.Get("/healthz", handler).SkipMiddleware()
+//
+// The decision which terminal route handles the request is made on method
+// and path alone, per-route predicates and query or attribute constraints
+// are not consulted for it.
+func (app *App) SkipMiddleware() Router {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
 	for _, routes := range app.stack {
 		for _, route := range routes {
-			if route.Name == name {
-				return *route
+			isMethodValid := route.Method == app.latestRoute.Method || app.latestRoute.use ||
+				(app.latestRoute.Method == MethodGet && route.Method == MethodHead)
+
+			if route.Path == app.latestRoute.Path && isMethodValid {
+				route.skipMiddleware = true
 			}
 		}
 	}
+	app.hasSkipMiddlewareRoutes = true
 
-	return Route{}
+	return app
 }
 
-// GetRoutes Get all routes. When filterUseOption equal to true, it will filter the routes registered by the middleware.
-func (app *App) GetRoutes(filterUseOption ...bool) []Route {
-	var rs []Route
-	var filterUse bool
-	if len(filterUseOption) != 0 {
-		filterUse = filterUseOption[0]
-	}
+// CacheControl assigns a Cache-Control directive to the latest registered route.
+// The directive is set on the response after the handler ran, unless the
+// handler already set its own Cache-Control header or returned an error.
+//
+//	app.Get("/config", handler).CacheControl("public, max-age=300")
+func (app *App) CacheControl(value string) Router {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
 	for _, routes := range app.stack {
 		for _, route := range routes {
-			if filterUse && route.use {
-				continue
+			isMethodValid := route.Method == app.latestRoute.Method || app.latestRoute.use ||
+				(app.latestRoute.Method == MethodGet && route.Method == MethodHead)
+
+			if route.Path == app.latestRoute.Path && isMethodValid {
+				route.cacheControl = value
 			}
-			rs = append(rs, *route)
 		}
 	}
-	return rs
+
+	return app
 }
 
-// Use registers a middleware route that will match requests
-// with the provided prefix (which is optional and defaults to "/").
+// LogAs ties the latest registered route to a named log format from
+// Config.LogFormats. After each request to the route finished, the format is
+// rendered with the request's method, path, matched route, status and
+// duration and written to Config.LogWriter. A name without a configured
+// format logs nothing.
 //
-//	app.Use(func(c *fiber.Ctx) error {
-//	     return c.Next()
-//	})
-//	app.Use("/api", func(c *fiber.Ctx) error {
-//	     return c.Next()
-//	})
-//	app.Use("/api", handler, func(c *fiber.Ctx) error {
-//	     return c.Next()
-//	})
+//	app.Get("/x", handler).LogAs("access")
+func (app *App) LogAs(name string) Router {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			isMethodValid := route.Method == app.latestRoute.Method || app.latestRoute.use ||
+				(app.latestRoute.Method == MethodGet && route.Method == MethodHead)
+
+			if route.Path == app.latestRoute.Path && isMethodValid {
+				route.logFormat = name
+			}
+		}
+	}
+
+	return app
+}
+
+// Related declares a relation between the latest registered route and a named
+// route. After the handler ran successfully, a Link header per RFC 8288 is
+// appended to the response, pointing at the related route's URL with the
+// current request's parameters filled in. Relations to names that no route
+// carries are silently skipped.
 //
-// This method will match all HTTP verbs: GET, POST, PUT, HEAD etc...
-func (app *App) Use(args ...interface{}) Router {
-	var prefix string
-	var prefixes []string
-	var handlers []Handler
+//	app.Get("/page/:nr", handler).Related("next", "page.next")
+func (app *App) Related(rel, routeName string) Router {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
 
-	for i := 0; i < len(args); i++ {
-		switch arg := args[i].(type) {
-		case string:
-			prefix = arg
-		case []string:
-			prefixes = arg
-		case Handler:
-			handlers = append(handlers, arg)
-		default:
-			panic(fmt.Sprintf("use: invalid handler %v\n", reflect.TypeOf(arg)))
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			isMethodValid := route.Method == app.latestRoute.Method || app.latestRoute.use ||
+				(app.latestRoute.Method == MethodGet && route.Method == MethodHead)
+
+			if route.Path == app.latestRoute.Path && isMethodValid {
+				route.related = append(route.related, routeRelation{rel: rel, routeName: routeName})
+			}
 		}
 	}
 
-	if len(prefixes) == 0 {
-		prefixes = append(prefixes, prefix)
+	return app
+}
+
+// Scheme restricts the latest registered route to requests of the given
+// scheme, "http" or "https". An "https"-only route requested over plain http
+// is answered with a 301 redirect to its https URL, unless
+// DisableSchemeRedirect is set, in which case the route simply does not match.
+//
+//	app.Get("/secure", handler).Scheme("https")
+func (app *App) Scheme(scheme string) Router {
+	if scheme != schemeHTTP && scheme != schemeHTTPS {
+		panic(fmt.Sprintf("scheme: invalid scheme %q, expected %q or %q\n", scheme, schemeHTTP, schemeHTTPS))
 	}
 
-	for _, prefix := range prefixes {
-		app.register(methodUse, prefix, nil, handlers...)
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	// A repeated registration on the same path is merged into the existing
+	// route's handler chain; carve it back out first, the restriction must
+	// not apply to the routes it was merged with
+	if !app.latestRoute.use {
+		app.splitMergedRoute()
+	}
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			// Like Query, the restriction must not leak onto earlier routes
+			// sharing the path - those are the per-scheme alternatives
+			// Scheme exists to distinguish
+			isPairedHead := app.latestRoute.Method == MethodGet && route.Method == MethodHead &&
+				route.Path == app.latestRoute.Path && route.pos+1 == app.latestRoute.pos
+			isUseCopy := app.latestRoute.use && route.use && route.Path == app.latestRoute.Path
+
+			if route == app.latestRoute || isPairedHead || isUseCopy {
+				route.scheme = scheme
+			}
+		}
 	}
 
 	return app
 }
 
+// Consumes restricts the latest registered route to requests whose
+// Content-Type matches one of the given media types, e.g. "application/json"
+// or the wildcard "application/*". Requests without a body and without a
+// Content-Type pass, unless ConsumesRequireContentType is set. Several
+// routes on one path may declare different types, the request's
+// Content-Type then dispatches between them in registration order; a body
+// no candidate accepts is answered with 415 Unsupported Media Type:
+//
+//	app.Post("/upload", jsonHandler).Consumes("application/json")
+//	app.Post("/upload", formHandler).Consumes("multipart/form-data")
+func (app *App) Consumes(types ...string) Router {
+	accepted := make([]string, len(types))
+	for i, t := range types {
+		accepted[i] = utils.ToLower(utils.Trim(t, ' '))
+	}
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	// A repeated registration on the same path is merged into the existing
+	// route's handler chain; carve it back out first, the accepted types
+	// must not apply to the routes it was merged with
+	if !app.latestRoute.use {
+		app.splitMergedRoute()
+	}
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			// Like Query, the accepted types must not leak onto earlier
+			// routes sharing the path - those are the Content-Type dispatch
+			// alternatives Consumes exists to distinguish
+			isPairedHead := app.latestRoute.Method == MethodGet && route.Method == MethodHead &&
+				route.Path == app.latestRoute.Path && route.pos+1 == app.latestRoute.pos
+			isUseCopy := app.latestRoute.use && route.use && route.Path == app.latestRoute.Path
+
+			if route == app.latestRoute || isPairedHead || isUseCopy {
+				route.consumes = accepted
+			}
+		}
+	}
+
+	return app
+}
+
+// DisableCompression marks the latest registered route as serving content
+// that must not be (re-)compressed, e.g. images or pre-gzipped blobs.
+// Compression middlewares honor the flag by checking
+// c.Route().CompressionDisabled() on the matched route.
+//
+//	app.Get("/img/*", handler).DisableCompression()
+func (app *App) DisableCompression() Router {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			isMethodValid := route.Method == app.latestRoute.Method || app.latestRoute.use ||
+				(app.latestRoute.Method == MethodGet && route.Method == MethodHead)
+
+			if route.Path == app.latestRoute.Path && isMethodValid {
+				route.compressionDisabled = true
+			}
+		}
+	}
+
+	return app
+}
+
+// AllowUpgrade marks the latest registered route as handling protocol
+// upgrades itself, exempting it from Config.UpgradeHandler.
+//
+//	app.Get("/ws", websocketHandler).AllowUpgrade()
+func (app *App) AllowUpgrade() Router {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			isMethodValid := route.Method == app.latestRoute.Method || app.latestRoute.use ||
+				(app.latestRoute.Method == MethodGet && route.Method == MethodHead)
+
+			if route.Path == app.latestRoute.Path && isMethodValid {
+				route.allowsUpgrade = true
+			}
+		}
+	}
+
+	return app
+}
+
+// Validate prepends a validation middleware, generated from the given struct
+// schema, to the handler chain of the latest registered route. The schema is
+// reflected over once at registration: fields tagged `validate:"required"`
+// must be non-zero after parsing the request body into the schema type.
+// Invalid bodies are answered with 400 Bad Request and a JSON body of the
+// shape {"errors":[{"field":"name","message":"is required"}]}.
+//
+//	app.Post("/users", handler).Validate(&CreateUserRequest{})
+func (app *App) Validate(schema interface{}) Router {
+	validator := buildValidationHandler(schema)
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			isMethodValid := route.Method == app.latestRoute.Method || app.latestRoute.use ||
+				(app.latestRoute.Method == MethodGet && route.Method == MethodHead)
+
+			if route.Path == app.latestRoute.Path && isMethodValid {
+				route.Handlers = append([]Handler{validator}, route.Handlers...)
+				atomic.AddUint32(&app.handlersCount, 1)
+			}
+		}
+	}
+
+	return app
+}
+
+// Query constrains the latest registered route to requests whose query
+// string carries the given param with exactly the given value, so several
+// handlers on one path can be dispatched by a query param. Calling Query
+// repeatedly adds further constraints that must all hold. Among routes on
+// the same path, the one with the most satisfied constraints wins; equal
+// counts fall back to registration order, and an unconstrained route acts
+// as the fallback.
+//
+//	app.Get("/search", imageHandler).Query("type", "image")
+//	app.Get("/search", videoHandler).Query("type", "video")
+//	app.Get("/search", defaultHandler)
+func (app *App) Query(key, value string) Router {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	// A repeated registration on the same path is merged into the existing
+	// route's handler chain; carve it back out first, the constraints must
+	// not apply to the routes it was merged with
+	if !app.latestRoute.use {
+		app.splitMergedRoute()
+	}
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			// Unlike the other latest-route mutations, constraints must not
+			// leak onto earlier routes sharing the path - those are exactly
+			// the alternatives Query exists to distinguish. Only the route
+			// itself, its paired HEAD route and per-method copies of a
+			// middleware are touched.
+			isPairedHead := app.latestRoute.Method == MethodGet && route.Method == MethodHead &&
+				route.Path == app.latestRoute.Path && route.pos+1 == app.latestRoute.pos
+			isUseCopy := app.latestRoute.use && route.use && route.Path == app.latestRoute.Path
+
+			if route == app.latestRoute || isPairedHead || isUseCopy {
+				if route.queryConstraints == nil {
+					route.queryConstraints = make(map[string]string)
+				}
+				route.queryConstraints[key] = value
+			}
+		}
+	}
+
+	return app
+}
+
+// Attr constrains the latest registered route to requests whose Ctx carries
+// the given attribute with exactly the given value. Attributes are resolved
+// before matching by hooks registered via OnPreRoute, typically from a token
+// or host header, which decouples attribute resolution from routing. Calling
+// Attr repeatedly adds further constraints that must all hold; an
+// unconstrained route on the same path registered later acts as the fallback.
+//
+//	app.OnPreRoute(func(c *Ctx) { c.SetAttr("tenant", resolveTenant(c)) })
+//	app.Get("/x", premiumHandler).Attr("tenant", "premium")
+//	app.Get("/x", defaultHandler)
+func (app *App) Attr(key, value string) Router {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	// A repeated registration on the same path is merged into the existing
+	// route's handler chain; carve it back out first, the constraints must
+	// not apply to the routes it was merged with
+	if !app.latestRoute.use {
+		app.splitMergedRoute()
+	}
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			// Like Query, the constraint must not leak onto earlier routes
+			// sharing the path - those are the alternatives Attr exists to
+			// distinguish
+			isPairedHead := app.latestRoute.Method == MethodGet && route.Method == MethodHead &&
+				route.Path == app.latestRoute.Path && route.pos+1 == app.latestRoute.pos
+			isUseCopy := app.latestRoute.use && route.use && route.Path == app.latestRoute.Path
+
+			if route == app.latestRoute || isPairedHead || isUseCopy {
+				if route.attrConstraints == nil {
+					route.attrConstraints = make(map[string]string)
+				}
+				route.attrConstraints[key] = value
+			}
+		}
+	}
+
+	return app
+}
+
+// SetRouteEnabled toggles the routes registered for the given method and
+// path at runtime. A disabled route is skipped during matching as if it were
+// not registered, falling through to the next candidate or 404. The flag is
+// read atomically per request, so toggling is concurrency-safe and needs no
+// route tree rebuild. Useful for feature toggles and maintenance switches.
+func (app *App) SetRouteEnabled(method, path string, enabled bool) {
+	method = utils.ToUpper(method)
+	var value int32
+	if !enabled {
+		value = 1
+	}
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			// toggling GET also covers the HEAD route Get registers
+			isPairedHead := method == MethodGet && route.Method == MethodHead
+			if (route.Method == method || isPairedHead) && route.Path == path {
+				atomic.StoreInt32(&route.disabled, value)
+			}
+		}
+	}
+}
+
+// maintenanceMode holds the handler and path allowlist served while
+// maintenance mode is on, swapped atomically as one value
+type maintenanceMode struct {
+	handler   Handler
+	allowlist map[string]struct{}
+}
+
+// SetMaintenanceMode toggles maintenance mode. While on, every request is
+// answered by the given handler instead of going through routing, except
+// requests whose path exactly matches an allowlisted path - compared like
+// routing does, i.e. case-insensitively and ignoring trailing slashes unless
+// CaseSensitive/StrictRouting are set. The switch is atomic, it can be
+// flipped at runtime without a route tree rebuild.
+//
+//	app.SetMaintenanceMode(true, maintenanceHandler, "/health")
+//	app.SetMaintenanceMode(false, nil)
+func (app *App) SetMaintenanceMode(on bool, handler Handler, allowlist ...string) {
+	if !on {
+		app.maintenance.Store((*maintenanceMode)(nil))
+		return
+	}
+	if handler == nil {
+		panic("maintenance: nil handler\n")
+	}
+	allow := make(map[string]struct{}, len(allowlist))
+	for _, path := range allowlist {
+		if path == "" {
+			path = "/"
+		}
+		if path[0] != '/' {
+			path = "/" + path
+		}
+		// normalize like the detection path the paths are compared against
+		if !app.config.CaseSensitive {
+			path = utils.ToLower(path)
+		}
+		if !app.config.StrictRouting && len(path) > 1 {
+			path = utils.TrimRight(path, '/')
+		}
+		allow[path] = struct{}{}
+	}
+	app.maintenance.Store(&maintenanceMode{handler: handler, allowlist: allow})
+}
+
+// MiddlewareOverlaps reports sets of use-middleware routes whose prefixes
+// overlap, i.e. requests under the longer prefix also run the middleware of
+// the shorter one. Overlap follows the boundary-aware prefix matching of use
+// routes, so "/api" overlaps "/api/v1" but not "/apiary". Each set is ordered
+// from the shortest to the longest prefix. This is a diagnostic for review,
+// overlapping middleware is often intended.
+func (app *App) MiddlewareOverlaps() [][]Route {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	// collect the distinct middleware prefixes once, use routes are
+	// mirrored across all method stacks
+	seen := make(map[string]Route)
+	var prefixes []string
+	for _, route := range app.stack[0] {
+		if !route.use || route.mount {
+			continue
+		}
+		if _, ok := seen[route.path]; !ok {
+			seen[route.path] = *route
+			prefixes = append(prefixes, route.path)
+		}
+	}
+	sort.Strings(prefixes)
+
+	var overlaps [][]Route
+	for i, prefix := range prefixes {
+		group := []Route{seen[prefix]}
+		for j := i + 1; j < len(prefixes); j++ {
+			if middlewarePrefixOverlaps(prefix, prefixes[j]) {
+				group = append(group, seen[prefixes[j]])
+			}
+		}
+		if len(group) > 1 {
+			overlaps = append(overlaps, group)
+		}
+	}
+	return overlaps
+}
+
+// middlewarePrefixOverlaps reports whether middleware registered at short
+// also runs for requests under long, mirroring the boundary check in
+// Route.match
+func middlewarePrefixOverlaps(short, long string) bool {
+	if !strings.HasPrefix(long, short) {
+		return false
+	}
+	return short == "/" || len(long) == len(short) || long[len(short)] == '/' || short[len(short)-1] == '/'
+}
+
+// rewriteRule maps a compiled route pattern to a replacement path, see
+// AddRewrite and AddRedirectRewrite
+type rewriteRule struct {
+	parser      routeParser // compiled pattern
+	replacement string      // target path with param placeholders
+	redirect    int         // redirect status, 0 rewrites internally
+}
+
+// AddRewrite registers a rewrite rule applied before routing: requests whose
+// path matches the pattern continue matching with the rewritten path, while
+// Ctx.OriginalURL keeps reporting the URL the client requested. Params
+// captured by the pattern are substituted into the replacement:
+//
+//	app.AddRewrite("/old/:id", "/new/:id")
+//
+// Rules are checked in registration order, the first matching rule wins.
+func (app *App) AddRewrite(pattern, replacement string) {
+	app.addRewrite(pattern, replacement, 0)
+}
+
+// AddRedirectRewrite registers a rewrite rule like AddRewrite, but answers
+// matching requests with a redirect to the rewritten path instead of
+// rewriting internally. The status defaults to 301 Moved Permanently.
+func (app *App) AddRedirectRewrite(pattern, replacement string, status ...int) {
+	code := StatusMovedPermanently
+	if len(status) > 0 {
+		code = status[0]
+	}
+	app.addRewrite(pattern, replacement, code)
+}
+
+func (app *App) addRewrite(pattern, replacement string, redirect int) {
+	if pattern == "" {
+		pattern = "/"
+	}
+	if pattern[0] != '/' {
+		pattern = "/" + pattern
+	}
+	// compile against the normalized form the detection path uses
+	if !app.config.CaseSensitive {
+		pattern = utils.ToLower(pattern)
+	}
+	if !app.config.StrictRouting && len(pattern) > 1 {
+		pattern = utils.TrimRight(pattern, '/')
+	}
+
+	app.mutex.Lock()
+	app.rewrites = append(app.rewrites, rewriteRule{
+		parser:      app.parsePattern(pattern),
+		replacement: replacement,
+		redirect:    redirect,
+	})
+	app.mutex.Unlock()
+}
+
+// applyRewrites runs the rewrite rules against the request and reports
+// whether the request was answered directly with a redirect
+func (app *App) applyRewrites(c *Ctx) bool {
+	var params [maxParams]string
+	for i := range app.rewrites {
+		rule := &app.rewrites[i]
+		if !rule.parser.getMatch(c.detectionPath, c.path, &params, false) {
+			continue
+		}
+		// substitute the captured params into the replacement, wildcard and
+		// plus captures replace their bare sign one at a time
+		target := rule.replacement
+		for j, name := range rule.parser.params {
+			switch name[0] {
+			case wildcardParam, plusParam:
+				target = strings.Replace(target, string(name[0]), params[j], 1)
+			default:
+				target = strings.ReplaceAll(target, ":"+name, params[j])
+			}
+		}
+		if rule.redirect > 0 {
+			_ = c.Redirect(target, rule.redirect) //nolint:errcheck // Redirect never fails
+			return true
+		}
+		c.rewritePath(target)
+		return false
+	}
+	return false
+}
+
+// splitMergedRoute undoes the duplicate-path merge addRoute applied to the
+// latest registration: the merged-in handlers become a route of their own
+// again, including the paired HEAD route of a GET registration. A no-op when
+// the latest route was not merged. Callers must hold app.mutex.
+func (app *App) splitMergedRoute() {
+	latest := app.latestRoute
+	m := app.methodInt(latest.Method)
+	if m == -1 {
+		return
+	}
+	for _, route := range app.stack[m] {
+		if route == latest {
+			// not merged, the route is its own stack entry
+			return
+		}
+	}
+
+	methods := []int{m}
+	if latest.Method == MethodGet {
+		if h := app.methodInt(MethodHead); h != -1 {
+			methods = []int{h, m}
+		}
+	}
+	for _, mi := range methods {
+		stack := app.stack[mi]
+		for i := len(stack) - 1; i >= 0; i-- {
+			preRoute := stack[i]
+			if preRoute.Path != latest.Path || preRoute.use || preRoute.mount {
+				continue
+			}
+			if len(preRoute.Handlers) <= len(latest.Handlers) {
+				break
+			}
+			split := app.copyRoute(preRoute)
+			split.Handlers = preRoute.Handlers[len(preRoute.Handlers)-len(latest.Handlers):]
+			split.queryConstraints = nil
+			split.attrConstraints = nil
+			preRoute.Handlers = preRoute.Handlers[:len(preRoute.Handlers)-len(latest.Handlers)]
+			split.pos = atomic.AddUint32(&app.routesCount, 1)
+			app.stack[mi] = append(stack, split)
+			if mi == m {
+				app.latestRoute = split
+			}
+			break
+		}
+	}
+	app.routesRefreshed = true
+}
+
+// When attaches a predicate to the latest registered route. The route only
+// matches when the predicate returns true, otherwise route scanning continues
+// with the next candidate, allowing several routes on the same path to be
+// distinguished by headers, feature flags and the like.
+//
+// Note that the predicate runs on every candidate match of the route, so it
+// should be cheap; expensive checks belong into middleware instead.
+//
+//	app.Get("/x", betaHandler).When(func(c *Ctx) bool { return c.Get("X-Beta") == "1" })
+//	app.Get("/x", defaultHandler)
+func (app *App) When(predicate func(*Ctx) bool) Router {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	// A repeated registration on the same path is merged into the existing
+	// route's handler chain; carve it back out first, the predicate must
+	// not apply to the routes it was merged with
+	if !app.latestRoute.use {
+		app.splitMergedRoute()
+	}
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			// Like Query, the predicate must not leak onto earlier routes
+			// sharing the path - those are exactly the alternatives When
+			// exists to distinguish
+			isPairedHead := app.latestRoute.Method == MethodGet && route.Method == MethodHead &&
+				route.Path == app.latestRoute.Path && route.pos+1 == app.latestRoute.pos
+			isUseCopy := app.latestRoute.use && route.use && route.Path == app.latestRoute.Path
+
+			if route == app.latestRoute || isPairedHead || isUseCopy {
+				route.predicate = predicate
+			}
+		}
+	}
+
+	return app
+}
+
+// Clone returns a fresh App carrying the same configuration and a deep copy
+// of the registered route stacks, with its own Ctx pool, counters and route
+// tree. Handler chains are copied as well, so registering routes or
+// middleware on the clone never affects the original. Useful for testing
+// variations of a route table without re-registering everything.
+func (app *App) Clone() *App {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	clone := New(app.configured)
+
+	// Deep-copy the route stacks
+	for m := range app.stack {
+		for _, route := range app.stack[m] {
+			r := clone.copyRoute(route)
+			// own the handler slice so appends on either side stay private
+			r.Handlers = make([]Handler, len(route.Handlers))
+			copy(r.Handlers, route.Handlers)
+			// keep the group reference, mounted routes need it for resolving
+			r.group = route.group
+			clone.stack[m] = append(clone.stack[m], r)
+		}
+	}
+	clone.routesCount = app.routesCount
+	clone.handlersCount = app.handlersCount
+	clone.routesRefreshed = true
+
+	// Share the mounted sub-app list so mounts resolve in the clone too
+	for prefix, sub := range app.mountFields.appList {
+		if prefix == "" {
+			continue
+		}
+		clone.mountFields.appList[prefix] = sub
+	}
+
+	return clone
+}
+
+// GetRoute Get route by name
+func (app *App) GetRoute(name string) Route {
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			if route.Name == name {
+				return *route
+			}
+		}
+	}
+
+	return Route{}
+}
+
+// GetRoutes Get all routes. When filterUseOption equal to true, it will filter the routes registered by the middleware.
+func (app *App) GetRoutes(filterUseOption ...bool) []Route {
+	var rs []Route
+	var filterUse bool
+	if len(filterUseOption) != 0 {
+		filterUse = filterUseOption[0]
+	}
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			if filterUse && route.use {
+				continue
+			}
+			rs = append(rs, *route)
+		}
+	}
+	return rs
+}
+
+// routeIntrospection is the JSON shape served by the route introspection endpoint.
+type routeIntrospection struct {
+	Method   string   `json:"method"`
+	Path     string   `json:"path"`
+	Params   []string `json:"params"`
+	Handlers int      `json:"handlers"`
+}
+
+// EnableRouteIntrospectionEndpoint registers a GET route on the given path that
+// returns the application's route table as JSON. Each entry carries the method,
+// path, param names and handler count of a registered route; the introspection
+// route itself is part of the output. The endpoint is opt-in and any handlers
+// passed before it runs can guard it:
+//
+//	app.EnableRouteIntrospectionEndpoint("/_routes", basicauth.New(cfg))
+func (app *App) EnableRouteIntrospectionEndpoint(path string, middleware ...Handler) Router {
+	handlers := append(middleware, func(c *Ctx) error {
+		routes := c.App().GetRoutes(true)
+		list := make([]routeIntrospection, 0, len(routes))
+		for _, route := range routes {
+			list = append(list, routeIntrospection{
+				Method:   route.Method,
+				Path:     route.Path,
+				Params:   route.Params,
+				Handlers: len(route.Handlers),
+			})
+		}
+		return c.JSON(list)
+	})
+
+	return app.Get(path, handlers...)
+}
+
+// ExportRoutes renders the registered route table as JSON, portable enough to
+// diff between deploys or feed external doc generators. The output reuses the
+// JSON tags on Route - method, name, path and param keys - while handler
+// functions are excluded by Route's json:"-" tag. Middleware routes are
+// filtered out, matching the route introspection endpoint.
+func (app *App) ExportRoutes() ([]byte, error) {
+	data, err := json.Marshal(app.GetRoutes(true))
+	if err != nil {
+		return nil, fmt.Errorf("export routes: %w", err)
+	}
+	return data, nil
+}
+
+// ValidateExportedRoutes checks that data is a well-formed ExportRoutes
+// payload: valid JSON holding a list of routes that each carry a method and a
+// rooted path. Custom methods are possible via Config.RequestMethods, so the
+// method value is only required to be present, not recognized.
+func ValidateExportedRoutes(data []byte) error {
+	var routes []Route
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return fmt.Errorf("validate exported routes: %w", err)
+	}
+	for i, route := range routes {
+		if route.Method == "" {
+			return fmt.Errorf("validate exported routes: entry %d has no method", i)
+		}
+		if route.Path == "" || route.Path[0] != '/' {
+			return fmt.Errorf("validate exported routes: entry %d has invalid path %q", i, route.Path)
+		}
+	}
+	return nil
+}
+
+// AllowedMethods returns the sorted, de-duplicated set of HTTP methods that
+// have a route matching the given concrete path, resolving params and
+// wildcards. Middleware registered via Use is not taken into account. It is
+// the building block for automatic OPTIONS responses and Allow headers.
+func (app *App) AllowedMethods(path string) []string {
+	// prepare the app for the start to make sure the route tree is built
+	app.startupProcess()
+
+	return app.allowedMethods(path)
+}
+
+// allowedMethods is the lock-free core of AllowedMethods, it expects the
+// route tree to be built already and is safe to call per request.
+func (app *App) allowedMethods(path string) []string {
+	// Normalize the path the same way the router does for route recognition
+	if path == "" {
+		path = "/"
+	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
+	detectionPath := path
+	if !app.config.CaseSensitive {
+		detectionPath = utils.ToLower(detectionPath)
+	}
+	if !app.config.StrictRouting && len(detectionPath) > 1 {
+		detectionPath = utils.TrimRight(detectionPath, '/')
+	}
+	treePath := ""
+	const maxDetectionPaths = 3
+	if len(detectionPath) >= maxDetectionPaths {
+		treePath = detectionPath[:maxDetectionPaths]
+	}
+
+	var methods []string
+	var values [maxParams]string
+	treeStack := app.getTreeStack()
+	for m, method := range app.config.RequestMethods {
+		tree, ok := treeStack[m][treePath]
+		if !ok {
+			tree = treeStack[m][""]
+		}
+		for _, route := range tree {
+			// Skip middleware and mounted apps
+			if route.use || route.mount {
+				continue
+			}
+			if route.match(detectionPath, path, &values) {
+				methods = append(methods, method)
+				break
+			}
+		}
+	}
+	sort.Strings(methods)
+
+	return methods
+}
+
+// ParamNames returns the param names declared by the route that would match
+// the given method and concrete path, in declaration order, without running
+// any handlers. Middleware registered via Use is not taken into account.
+// Useful for generic middleware that needs to know which segments of a
+// request path carry IDs:
+//
+//	app.Get("/users/:id/books/:title", handler)
+//	app.ParamNames(MethodGet, "/users/42/books/fiber") // [id title]
+//
+// It returns nil when the method is unknown or no route matches the path.
+func (app *App) ParamNames(method, path string) []string {
+	// prepare the app for the start to make sure the route tree is built
+	app.startupProcess()
+
+	methodINT := app.methodInt(method)
+	if methodINT == -1 {
+		return nil
+	}
+
+	// Normalize the path the same way the router does for route recognition
+	if path == "" {
+		path = "/"
+	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
+	detectionPath := path
+	if !app.config.CaseSensitive {
+		detectionPath = utils.ToLower(detectionPath)
+	}
+	if !app.config.StrictRouting && len(detectionPath) > 1 {
+		detectionPath = utils.TrimRight(detectionPath, '/')
+	}
+	treePath := ""
+	const maxDetectionPaths = 3
+	if len(detectionPath) >= maxDetectionPaths {
+		treePath = detectionPath[:maxDetectionPaths]
+	}
+
+	var values [maxParams]string
+	treeStack := app.getTreeStack()
+	tree, ok := treeStack[methodINT][treePath]
+	if !ok {
+		tree = treeStack[methodINT][""]
+	}
+	for _, route := range tree {
+		// Skip middleware and mounted apps
+		if route.use || route.mount {
+			continue
+		}
+		if route.match(detectionPath, path, &values) {
+			return route.Params
+		}
+	}
+
+	return nil
+}
+
+// RoutesHash returns a deterministic hash over the registered route table.
+// The hash covers the method, path and param keys of every route, but not
+// the handlers, so two builds exposing the same route table hash identically
+// regardless of registration order. Useful to detect unexpected route
+// changes between deployments.
+func (app *App) RoutesHash() string {
+	// prepare the app for the start to make sure the route tree is built
+	app.startupProcess()
+
+	entries := make([]string, 0, app.routesCount)
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			entries = append(entries, route.Method+" "+route.Path+" "+strings.Join(route.Params, ","))
+		}
+	}
+	// Sort the entries so the hash is independent of the registration order
+	sort.Strings(entries)
+
+	hash := sha256.New()
+	for _, entry := range entries {
+		_, _ = hash.Write([]byte(entry + "\n")) //nolint:errcheck // Write to a hash never fails
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Use registers a middleware route that will match requests
+// with the provided prefix (which is optional and defaults to "/").
+//
+//	app.Use(func(c *fiber.Ctx) error {
+//	     return c.Next()
+//	})
+//	app.Use("/api", func(c *fiber.Ctx) error {
+//	     return c.Next()
+//	})
+//	app.Use("/api", handler, func(c *fiber.Ctx) error {
+//	     return c.Next()
+//	})
+//
+// This method will match all HTTP verbs: GET, POST, PUT, HEAD etc...
+func (app *App) Use(args ...interface{}) Router {
+	var prefix string
+	var prefixes []string
+	var handlers []Handler
+
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i].(type) {
+		case string:
+			prefix = arg
+		case []string:
+			prefixes = arg
+		case Handler:
+			handlers = append(handlers, arg)
+		default:
+			panic(fmt.Sprintf("use: invalid handler %v\n", reflect.TypeOf(arg)))
+		}
+	}
+
+	if len(prefixes) == 0 {
+		prefixes = append(prefixes, prefix)
+	}
+
+	for _, prefix := range prefixes {
+		app.register(methodUse, prefix, nil, handlers...)
+	}
+
+	return app
+}
+
+// UseFirst registers a middleware route like Use, but makes it sort before
+// every route registered so far, so the middleware runs first on its prefix
+// regardless of registration order. Calling UseFirst again slots the newer
+// middleware in front of the older one.
+//
+// Route positions are unsigned and normally only grow, so UseFirst rebases
+// the whole table: existing routes shift up and the new middleware copies
+// take the freed positions at the front.
+func (app *App) UseFirst(prefix string, handlers ...Handler) Router {
+	prevCount := atomic.LoadUint32(&app.routesCount)
+	app.registerWithOptions(methodUse, prefix, nil, registerOptions{pinned: true}, handlers...)
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	delta := atomic.LoadUint32(&app.routesCount) - prevCount
+	// A route pointer can sit in several method stacks (GET/HEAD share
+	// static routes), rebase each route exactly once
+	seen := make(map[*Route]struct{})
+	for m := range app.stack {
+		for _, route := range app.stack[m] {
+			if _, ok := seen[route]; ok {
+				continue
+			}
+			seen[route] = struct{}{}
+			if route.pos > prevCount {
+				// Newly registered middleware copy, move it to the front
+				route.pos -= prevCount
+			} else {
+				// Pre-existing route, shift it up to make room
+				route.pos += delta
+			}
+		}
+	}
+	app.routesRefreshed = true
+
+	return app
+}
+
 // Get registers a route for GET methods that requests a representation
 // of the specified resource. Requests using GET should only retrieve data.
 func (app *App) Get(path string, handlers ...Handler) Router {
@@ -721,6 +2185,21 @@ func (app *App) Get(path string, handlers ...Handler) Router {
 
 // Head registers a route for HEAD methods that asks for a response identical
 // to that of a GET request, but without the response body.
+//
+// Handlers shared between GET and HEAD can skip the (possibly expensive)
+// body generation for HEAD requests and still announce the correct size:
+//
+//	app.Get("/report", func(c *fiber.Ctx) error {
+//	    if c.Method() == fiber.MethodHead {
+//	        c.Set(fiber.HeaderContentLength, strconv.Itoa(reportSize()))
+//	        return nil
+//	    }
+//	    return c.Send(buildReport())
+//	})
+//
+// An explicitly set Content-Length header is preserved on HEAD responses
+// even though the body stays empty; if the handler does write a body, it is
+// stripped from the response while Content-Length is kept.
 func (app *App) Head(path string, handlers ...Handler) Router {
 	return app.Add(MethodHead, path, handlers...)
 }
@@ -773,6 +2252,209 @@ func (app *App) Add(method, path string, handlers ...Handler) Router {
 	return app
 }
 
+// AddDynamic registers a route like Add but also makes it matchable right
+// away by inserting it into the live route tree incrementally instead of
+// waiting for the next full rebuild, which re-sorts every bucket and gets
+// wasteful on large tables when routes are added one at a time at runtime.
+// Only the affected buckets are copied and swapped in atomically, see
+// Benchmark_App_TreeInsert_Incremental. Middleware still requires a full
+// rebuild, e.g. via RebuildTreeAsync.
+func (app *App) AddDynamic(method, path string, handlers ...Handler) Router {
+	prevCount := atomic.LoadUint32(&app.routesCount)
+	app.register(method, path, nil, handlers...)
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	// a registration merged into an existing route needs no tree change,
+	// the tree already points at the route the handlers were merged into
+	if atomic.LoadUint32(&app.routesCount) > prevCount {
+		app.insertRouteIntoTree(app.latestRoute)
+	}
+
+	return app
+}
+
+// AddExact registers a route whose path is matched exactly as written,
+// bypassing the CaseSensitive and StrictRouting normalization. This makes
+// "/foo" and "/foo/" distinct routes with distinct handlers regardless of
+// the global config, e.g. a collection versus a resource endpoint.
+func (app *App) AddExact(method, path string, handlers ...Handler) Router {
+	app.registerWithOptions(method, path, nil, registerOptions{exactPath: true}, handlers...)
+
+	return app
+}
+
+// UseApp applies the middleware of another App under the given prefix,
+// without importing its endpoints. This turns a standalone App configured
+// with middleware (auth, logging, ...) into a reusable bundle. Unlike Mount,
+// which pulls every route, only the app's Use registrations are re-registered
+// here, in their original order. Routes added to the bundle afterwards are
+// not picked up.
+//
+//	bundle := fiber.New()
+//	bundle.Use(authMiddleware)
+//	app.UseApp("/admin", bundle)
+func (app *App) UseApp(prefix string, mw *App) Router {
+	if prefix == "" {
+		prefix = "/"
+	}
+	// use routes are registered on every method stack, walking a single
+	// method therefore yields each of them exactly once, in order
+	for _, route := range mw.stack[0] {
+		if !route.use || route.mount {
+			continue
+		}
+		app.register(methodUse, getGroupPath(prefix, route.Path), nil, route.Handlers...)
+	}
+
+	return app
+}
+
+// AddRouteRef registers a route like Add but returns the created *Route
+// instead of the app, so callers can keep a handle for later inspection or
+// mutation (name, metadata). Note that the router matches against state
+// captured when the route tree was built: mutations made after the first
+// request only take effect once the tree is rebuilt, i.e. after the next
+// route registration.
+func (app *App) AddRouteRef(method, path string, handlers ...Handler) *Route {
+	app.register(method, path, nil, handlers...)
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	return app.latestRoute
+}
+
+// AddRouteIfAbsent registers the route only when no route with the same
+// method and path exists yet and reports whether it registered. Plugins that
+// may load twice use this to avoid the duplicate-path merge appending their
+// handlers to the already registered route.
+func (app *App) AddRouteIfAbsent(method, path string, handlers ...Handler) bool {
+	method = utils.ToUpper(method)
+	if path == "" {
+		path = "/"
+	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
+	// compare the normalized form like the router does, so "/Foo" and "/foo"
+	// count as the same route under case-insensitive routing
+	pathPretty := path
+	if !app.config.CaseSensitive {
+		pathPretty = utils.ToLower(pathPretty)
+	}
+	if !app.config.StrictRouting && len(pathPretty) > 1 {
+		pathPretty = utils.TrimRight(pathPretty, '/')
+	}
+
+	if m := app.methodInt(method); m != -1 {
+		app.mutex.Lock()
+		for _, route := range app.stack[m] {
+			if !route.use && route.path == pathPretty {
+				app.mutex.Unlock()
+				return false
+			}
+		}
+		app.mutex.Unlock()
+	}
+
+	app.register(method, path, nil, handlers...)
+	return true
+}
+
+// AddExactMethod registers a route whose method is matched in the exact case
+// given, bypassing the uppercase normalization register applies. The method
+// must be listed in Config.RequestMethods in the same case, otherwise the
+// registration panics like any unknown method. Request methods are never
+// normalized, so only requests carrying the method byte for byte match.
+// Useful when proxying clients that send lowercase custom methods.
+func (app *App) AddExactMethod(method, path string, handlers ...Handler) Router {
+	app.registerWithOptions(method, path, nil, registerOptions{exactMethod: true}, handlers...)
+
+	return app
+}
+
+// Resource registers one handler per HTTP method on a single path, so a
+// logical resource can be declared in one call:
+//
+//	app.Resource("/posts", map[string]fiber.Handler{
+//	    fiber.MethodGet:  list,
+//	    fiber.MethodPost: create,
+//	})
+//
+// The registered routes share the same path, grouping them in GetRoutes.
+// Requests using a method not present in the map are answered with a
+// 405 Method Not Allowed carrying an Allow header listing the map keys.
+func (app *App) Resource(path string, handlers map[string]Handler) Router {
+	// Sort the methods so the registration order is deterministic
+	methods := make([]string, 0, len(handlers))
+	for method := range handlers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		app.register(method, path, nil, handlers[method])
+	}
+
+	return app
+}
+
+// RouteSpec describes a single route for bulk registration via AddRoutes.
+// Middleware handlers run before the route's own Handlers.
+type RouteSpec struct {
+	Method     string
+	Path       string
+	Name       string
+	Middleware []Handler
+	Handlers   []Handler
+}
+
+// AddRoutes registers a slice of route specs in order, which is handy for
+// config-driven routing where the table comes from a registry or plugin.
+// Unlike the panicking registration methods it collects one error per invalid
+// spec and returns them. The specs are validated up front: if any spec is
+// invalid, nothing is registered at all, so a partial failure never leaves
+// the route table half-built.
+func (app *App) AddRoutes(specs []RouteSpec) []error {
+	var errs []error
+	for i, spec := range specs {
+		if app.methodInt(utils.ToUpper(spec.Method)) == -1 {
+			errs = append(errs, fmt.Errorf("route spec %d: invalid http method %q", i, spec.Method))
+			continue
+		}
+		if len(spec.Middleware)+len(spec.Handlers) == 0 {
+			errs = append(errs, fmt.Errorf("route spec %d: missing handler in route: %s", i, spec.Path))
+			continue
+		}
+	nilCheck:
+		for _, chain := range [][]Handler{spec.Middleware, spec.Handlers} {
+			for _, h := range chain {
+				if h == nil {
+					errs = append(errs, fmt.Errorf("route spec %d: nil handler in route: %s", i, spec.Path))
+					break nilCheck
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for _, spec := range specs {
+		handlers := make([]Handler, 0, len(spec.Middleware)+len(spec.Handlers))
+		handlers = append(handlers, spec.Middleware...)
+		handlers = append(handlers, spec.Handlers...)
+
+		router := app.Add(utils.ToUpper(spec.Method), spec.Path, handlers...)
+		if spec.Name != "" {
+			router.Name(spec.Name)
+		}
+	}
+
+	return nil
+}
+
 // Static will create a file server serving static files
 func (app *App) Static(prefix, root string, config ...Static) Router {
 	app.registerStatic(prefix, root, config...)
@@ -788,6 +2470,20 @@ func (app *App) All(path string, handlers ...Handler) Router {
 	return app
 }
 
+// Fallback registers a catch-all handler that runs for any request across
+// all methods and paths once the route stack is exhausted without a match,
+// taking precedence over the default 404 response. It does not override the
+// 405 determination: if the path matches a route under another method,
+// ErrMethodNotAllowed is still returned. Useful for SPA fallbacks or custom
+// proxying of unknown routes.
+func (app *App) Fallback(handler Handler) Router {
+	app.mutex.Lock()
+	app.fallbackHandler = handler
+	app.mutex.Unlock()
+
+	return app
+}
+
 // Group is used for Routes with common prefix to define a new sub-router with optional middleware.
 //
 //	api := app.Group("/api")
@@ -836,6 +2532,47 @@ func NewError(code int, message ...string) *Error {
 	return err
 }
 
+// AdaptContextHandler wraps a function expecting a context.Context into a
+// fiber Handler. The context derives from c.UserContext() and is canceled
+// when the client closes the connection or the handler returns, so wrapped
+// long-running work can be aborted when the client goes away. Errors
+// returned by fn flow into the app's error handler like any other handler
+// error.
+//
+// Note: disconnect detection reads from the connection and therefore does
+// not support clients that pipeline further requests while fn is running. It
+// is also disabled with Config.StreamRequestBody, where unread body bytes are
+// still on the connection and the probe read would consume one of them.
+func AdaptContextHandler(fn func(ctx context.Context, c *Ctx) error) Handler {
+	return func(c *Ctx) error {
+		ctx, cancel := context.WithCancel(c.UserContext())
+		defer cancel()
+
+		// Without body streaming fasthttp has fully buffered the request
+		// before the handler runs, so the next readable byte cannot belong
+		// to its body
+		if conn := c.Context().Conn(); conn != nil && !c.app.config.StreamRequestBody {
+			watcherDone := make(chan struct{})
+			go func() {
+				defer close(watcherDone)
+				defer cancel()
+				// The read unblocks when the client closes the connection or
+				// when the deadline poke below fires after fn returned
+				var probe [1]byte
+				_, _ = conn.Read(probe[:])            //nolint:errcheck // Any outcome means we are done watching
+				_ = conn.SetReadDeadline(time.Time{}) //nolint:errcheck // Restore the deadline for keep-alive reads
+			}()
+			defer func() {
+				// Unblock the probe read in case the client is still connected
+				_ = conn.SetReadDeadline(time.Now()) //nolint:errcheck // It is fine to ignore the error here
+				<-watcherDone
+			}()
+		}
+
+		return fn(ctx, c)
+	}
+}
+
 // Config returns the app config as value ( read-only ).
 func (app *App) Config() Config {
 	return app.config
@@ -899,7 +2636,15 @@ func (app *App) ShutdownWithContext(ctx context.Context) error {
 	return app.server.ShutdownWithContext(ctx)
 }
 
-// Server returns the underlying fasthttp server
+// Server returns the underlying fasthttp server, allowing advanced options
+// that are not surfaced in Config (e.g. TCPKeepalive, MaxConnsPerIP or
+// SleepWhenConcurrencyLimitsExceeded) to be tuned directly:
+//
+//	app := fiber.New()
+//	app.Server().MaxConnsPerIP = 10
+//
+// Modify the server before calling Listen; changing it while the server is
+// listening is unsafe.
 func (app *App) Server() *fasthttp.Server {
 	return app.server
 }
@@ -1001,8 +2746,12 @@ func (app *App) init() *App {
 	}
 
 	// create fasthttp server
+	var serverLogger fasthttp.Logger = &disableLogger{}
+	if app.config.Logger != nil {
+		serverLogger = app.config.Logger
+	}
 	app.server = &fasthttp.Server{
-		Logger:       &disableLogger{},
+		Logger:       serverLogger,
 		LogAllErrors: false,
 		ErrorHandler: app.serverErrorHandler,
 	}
@@ -1010,13 +2759,16 @@ func (app *App) init() *App {
 	// fasthttp server settings
 	app.server.Handler = app.handler
 	app.server.Name = app.config.ServerHeader
+	if app.config.DisableServerHeader {
+		app.server.Name = ""
+	}
 	app.server.Concurrency = app.config.Concurrency
 	app.server.NoDefaultDate = app.config.DisableDefaultDate
 	app.server.NoDefaultContentType = app.config.DisableDefaultContentType
 	app.server.DisableHeaderNamesNormalizing = app.config.DisableHeaderNormalizing
 	app.server.DisableKeepalive = app.config.DisableKeepalive
 	app.server.MaxRequestBodySize = app.config.BodyLimit
-	app.server.NoDefaultServerHeader = app.config.ServerHeader == ""
+	app.server.NoDefaultServerHeader = app.config.DisableServerHeader
 	app.server.ReadTimeout = app.config.ReadTimeout
 	app.server.WriteTimeout = app.config.WriteTimeout
 	app.server.IdleTimeout = app.config.IdleTimeout
@@ -1026,18 +2778,89 @@ func (app *App) init() *App {
 	app.server.ReduceMemoryUsage = app.config.ReduceMemoryUsage
 	app.server.StreamRequestBody = app.config.StreamRequestBody
 	app.server.DisablePreParseMultipartForm = app.config.DisablePreParseMultipartForm
+	app.server.ConnState = app.connStateHook
 
 	// unlock application
 	app.mutex.Unlock()
 	return app
 }
 
+// ConnMetrics is a point-in-time snapshot of the server's client
+// connections, maintained through the fasthttp connection-state callback.
+type ConnMetrics struct {
+	// Connections currently processing a request
+	Active int64 `json:"active"`
+	// Kept-alive connections currently waiting for the next request
+	Idle int64 `json:"idle"`
+	// Total connections closed while idle since the server started
+	ReapedIdle int64 `json:"reaped_idle"`
+}
+
+// ConnMetrics returns gauges of the currently active and idle connections
+// plus the total number of connections that were closed while idle, i.e.
+// reaped by IdleTimeout or dropped by the peer between requests.
+func (app *App) ConnMetrics() ConnMetrics {
+	return ConnMetrics{
+		Active:     atomic.LoadInt64(&app.activeConns),
+		Idle:       atomic.LoadInt64(&app.idleConns),
+		ReapedIdle: atomic.LoadInt64(&app.reapedConns),
+	}
+}
+
+// ActiveStreams returns the number of responses currently being streamed
+// via SendStream. Only maintained when Config.MaxConcurrentStreams is set.
+func (app *App) ActiveStreams() int64 {
+	return atomic.LoadInt64(&app.activeStreams)
+}
+
+// connStateHook follows every connection through its state transitions to
+// keep the ConnMetrics gauges current and to report reaped idle connections.
+func (app *App) connStateHook(conn net.Conn, state fasthttp.ConnState) {
+	prev, tracked := app.connStates.Load(conn)
+	prevState, _ := prev.(fasthttp.ConnState) //nolint:errcheck // zero value is fine for untracked conns
+
+	// Release the gauge of the state the connection leaves
+	if tracked {
+		switch prevState {
+		case fasthttp.StateActive:
+			atomic.AddInt64(&app.activeConns, -1)
+		case fasthttp.StateIdle:
+			atomic.AddInt64(&app.idleConns, -1)
+		}
+	}
+
+	switch state {
+	case fasthttp.StateNew:
+		app.connStates.Store(conn, state)
+	case fasthttp.StateActive:
+		atomic.AddInt64(&app.activeConns, 1)
+		app.connStates.Store(conn, state)
+	case fasthttp.StateIdle:
+		atomic.AddInt64(&app.idleConns, 1)
+		app.connStates.Store(conn, state)
+	case fasthttp.StateClosed, fasthttp.StateHijacked:
+		app.connStates.Delete(conn)
+		if tracked && prevState == fasthttp.StateIdle {
+			atomic.AddInt64(&app.reapedConns, 1)
+			if callback := app.config.OnIdleConnReaped; callback != nil {
+				callback(conn)
+			}
+		}
+	}
+}
+
 // ErrorHandler is the application's method in charge of finding the
 // appropriate handler for the given request. It searches any mounted
 // sub fibers by their prefixes and if it finds a match, it uses that
 // error handler. Otherwise it uses the configured error handler for
 // the app, which if not set is the DefaultErrorHandler.
 func (app *App) ErrorHandler(ctx *Ctx, err error) error {
+	// Type-keyed handlers registered via OnErrorType take precedence over
+	// the general handlers resolved below
+	if handler := app.errorHandlerFor(err); handler != nil {
+		return handler(ctx, err)
+	}
+
 	var (
 		mountedErrHandler  ErrorHandler
 		mountedPrefixParts int
@@ -1070,6 +2893,10 @@ func (app *App) serverErrorHandler(fctx *fasthttp.RequestCtx, err error) {
 	c := app.AcquireCtx(fctx)
 	defer app.ReleaseCtx(c)
 
+	// Reporters subscribed via OnError see the raw error before it is
+	// mapped to a fiber error
+	app.notifyError(c, err)
+
 	var (
 		errNetOP *net.OpError
 		netErr   net.Error
@@ -1077,15 +2904,36 @@ func (app *App) serverErrorHandler(fctx *fasthttp.RequestCtx, err error) {
 
 	switch {
 	case errors.As(err, new(*fasthttp.ErrSmallBuffer)):
+		// A dedicated hook can give better guidance than the general error
+		// handler, e.g. suggest trimming cookies
+		if handler := app.config.HeaderLimitHandler; handler != nil {
+			if catch := handler(c); catch == nil {
+				return
+			}
+			// the hook failed, fall back to the regular mapping
+		}
 		err = ErrRequestHeaderFieldsTooLarge
 	case errors.As(err, &errNetOP) && errNetOP.Timeout():
 		err = ErrRequestTimeout
+	// every error arriving here came out of fasthttp reading the request,
+	// so the raw connection-read classification is safe
+	case isConnectionReadError(err):
+		if app.config.SkipErrorHandlerOnDisconnect {
+			// the client is gone, nobody reads the response
+			return
+		}
+		err = ErrClientDisconnected
 	case errors.As(err, &netErr):
 		err = ErrBadGateway
 	case errors.Is(err, fasthttp.ErrBodyTooLarge):
 		err = ErrRequestEntityTooLarge
 	case errors.Is(err, fasthttp.ErrGetOnly):
 		err = ErrMethodNotAllowed
+	case errors.Is(err, fasthttp.ErrConcurrencyLimit):
+		// hint clients to back off before retrying when the concurrency
+		// cap is reached
+		c.Set(HeaderRetryAfter, "1")
+		err = ErrServiceUnavailable
 	case strings.Contains(err.Error(), "timeout"):
 		err = ErrRequestTimeout
 	default:
@@ -1094,11 +2942,49 @@ func (app *App) serverErrorHandler(fctx *fasthttp.RequestCtx, err error) {
 
 	if catch := app.ErrorHandler(c, err); catch != nil {
 		log.Errorf("serverErrorHandler: failed to call ErrorHandler: %v", catch)
-		_ = c.SendStatus(StatusInternalServerError) //nolint:errcheck // It is fine to ignore the error here
+		// Only force a 500 if nothing was written yet, overriding an
+		// already committed response would corrupt it
+		if !c.responseCommitted() {
+			_ = c.SendStatus(StatusInternalServerError) //nolint:errcheck // It is fine to ignore the error here
+		}
 		return
 	}
 }
 
+// Prewarm runs the startup process ahead of the first request, building the
+// route tree and exercising the matcher once per tree bucket so the first
+// real request does not pay the lazy initialization cost. This is mostly
+// useful in serverless environments with tight cold-start budgets.
+//
+// Prewarm is idempotent and safe to call multiple times.
+func (app *App) Prewarm() {
+	// prepare the app for the start, this builds the route tree
+	app.startupProcess()
+
+	// exercise the matcher once per method and tree bucket
+	var values [maxParams]string
+	treeStack := app.getTreeStack()
+	for m := range app.config.RequestMethods {
+		for treePath, tree := range treeStack[m] {
+			for _, route := range tree {
+				_ = route.match(treePath, treePath, &values)
+			}
+		}
+	}
+}
+
+// OnBuildTree registers a hook that runs right before the route tree is
+// (re)built, with access to the flat route stack. The hook may mutate the
+// routes, e.g. to inject an additional handler on every endpoint, but must
+// preserve the router's invariants: route positions stay unique and the
+// Method of a route keeps matching the method stack it lives in. The hook
+// runs once per tree build, not per request.
+func (app *App) OnBuildTree(handler ...OnBuildTreeHandler) {
+	app.mutex.Lock()
+	app.hooks.onBuildTree = append(app.hooks.onBuildTree, handler...)
+	app.mutex.Unlock()
+}
+
 // startupProcess Is the method which executes all the necessary processes just before the start of the server.
 func (app *App) startupProcess() *App {
 	app.mutex.Lock()
@@ -1106,12 +2992,183 @@ func (app *App) startupProcess() *App {
 
 	app.mountStartupProcess()
 
-	// build route tree stack
-	app.buildTree()
+	// let hooks inspect or mutate the route stack right before it is
+	// assembled into the tree, only when the tree is actually rebuilt
+	if app.routesRefreshed {
+		app.hooks.executeOnBuildTreeHooks()
+	}
+
+	// build route tree stack, record how long the rebuild took for
+	// startup observability on very large route tables
+	if app.routesRefreshed {
+		start := time.Now()
+		app.buildTree()
+		app.treeBuildDuration = time.Since(start)
+	}
+
+	// strict mode turns latent routing ambiguities into startup failures
+	if app.enforceUnambiguousRoutes {
+		if err := app.findAmbiguousRoutes(); err != nil {
+			panic(err)
+		}
+	}
 
 	return app
 }
 
+// EnforceUnambiguousRoutes makes startup fail when any two routes could
+// match the same concrete request ambiguously. Two routes are ambiguous
+// when they share a method, their patterns can match the same path and
+// neither is more specific than the other, i.e. they declare the same
+// number of literal, single-param and greedy segments. Routes guarded by a
+// predicate or query/attribute constraints are exempt, their overlap is
+// resolved per request by design, as is middleware. The check runs during
+// startupProcess and panics on the first ambiguous pair, so safety-critical
+// services surface latent routing bugs before serving:
+//
+//	app := fiber.New()
+//	app.EnforceUnambiguousRoutes()
+func (app *App) EnforceUnambiguousRoutes() {
+	app.mutex.Lock()
+	app.enforceUnambiguousRoutes = true
+	app.mutex.Unlock()
+}
+
+// findAmbiguousRoutes scans the route table pairwise for ambiguous routes,
+// see EnforceUnambiguousRoutes. O(n²) per method, only run at startup.
+func (app *App) findAmbiguousRoutes() error {
+	for m := range app.config.RequestMethods {
+		stack := app.stack[m]
+		for i := 0; i < len(stack); i++ {
+			if !routeNeedsAmbiguityCheck(stack[i]) {
+				continue
+			}
+			for j := i + 1; j < len(stack); j++ {
+				if !routeNeedsAmbiguityCheck(stack[j]) {
+					continue
+				}
+				if routesAmbiguous(stack[i], stack[j]) {
+					return fmt.Errorf("strict routes: %s %s and %s overlap with equal specificity",
+						stack[i].Method, stack[i].Path, stack[j].Path)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// routeNeedsAmbiguityCheck filters out the routes whose overlap is legal:
+// middleware, mounts and routes whose guards disambiguate them per request
+func routeNeedsAmbiguityCheck(route *Route) bool {
+	return !route.use && !route.mount && route.predicate == nil &&
+		len(route.queryConstraints) == 0 && len(route.attrConstraints) == 0
+}
+
+// routesAmbiguous reports whether two routes can match the same concrete
+// path without one being more specific than the other
+func routesAmbiguous(a, b *Route) bool {
+	segsA := strings.Split(a.path, "/")[1:]
+	segsB := strings.Split(b.path, "/")[1:]
+	if !routeSegmentsOverlap(segsA, segsB) {
+		return false
+	}
+	constsA, paramsA, greedyA := countSegmentKinds(segsA)
+	constsB, paramsB, greedyB := countSegmentKinds(segsB)
+	return constsA == constsB && paramsA == paramsB && greedyA == greedyB
+}
+
+// routeSegmentsOverlap reports whether two patterns can match the same
+// concrete path, walking them segment by segment. A greedy segment is
+// conservatively assumed to absorb any remainder.
+func routeSegmentsOverlap(a, b []string) bool {
+	for len(a) > 0 && len(b) > 0 {
+		if isGreedyRouteSegment(a[0]) || isGreedyRouteSegment(b[0]) {
+			return true
+		}
+		if !isParamRouteSegment(a[0]) && !isParamRouteSegment(b[0]) && a[0] != b[0] {
+			return false
+		}
+		a, b = a[1:], b[1:]
+	}
+	// a leftover tail only overlaps when it can match the empty remainder
+	for _, tail := range [][]string{a, b} {
+		for _, seg := range tail {
+			if !isGreedyRouteSegment(seg) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isGreedyRouteSegment reports whether the pattern segment can span more
+// than one path segment
+func isGreedyRouteSegment(seg string) bool {
+	return strings.ContainsAny(seg, "*+")
+}
+
+// isParamRouteSegment reports whether the pattern segment captures an
+// arbitrary single path segment
+func isParamRouteSegment(seg string) bool {
+	return strings.Contains(seg, ":")
+}
+
+// countSegmentKinds tallies a pattern's literal, single-param and greedy
+// segments, the specificity signature compared by routesAmbiguous
+func countSegmentKinds(segs []string) (consts, params, greedy int) {
+	for _, seg := range segs {
+		switch {
+		case isGreedyRouteSegment(seg):
+			greedy++
+		case isParamRouteSegment(seg):
+			params++
+		default:
+			consts++
+		}
+	}
+	return consts, params, greedy
+}
+
+// RebuildTreeAsync rebuilds the route tree in a background goroutine and
+// reports completion on the returned channel, which receives nil on success
+// or the rebuild error. The new tree is assembled off to the side and swapped
+// in with a single assignment, so in-flight requests keep matching against
+// the old tree until the swap and never miss a route that was registered
+// before the rebuild. Useful for apps registering routes in bulk at runtime
+// that cannot afford a synchronous rebuild on the request path.
+func (app *App) RebuildTreeAsync() <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("rebuild tree: %v", r)
+			}
+		}()
+		// the registration mutex keeps concurrent route changes out while
+		// the tree is assembled; requests do not take it and keep serving
+		app.mutex.Lock()
+		defer app.mutex.Unlock()
+		if app.routesRefreshed {
+			app.hooks.executeOnBuildTreeHooks()
+			start := time.Now()
+			app.buildTree()
+			app.treeBuildDuration = time.Since(start)
+		}
+		done <- nil
+	}()
+	return done
+}
+
+// LastTreeBuildDuration returns how long the last route tree rebuild took.
+// The tree is (re-)built on startup and whenever routes changed since the
+// previous build, so the value is zero until the first request or listen.
+func (app *App) LastTreeBuildDuration() time.Duration {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	return app.treeBuildDuration
+}
+
 // Run onListen hooks. If they return an error, panic.
 func (app *App) runOnListenHooks(listenData ListenData) {
 	if err := app.hooks.executeOnListenHooks(listenData); err != nil {
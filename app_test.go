@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -19,7 +20,9 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -282,6 +285,45 @@ func Test_App_serverErrorHandler_Network_Error(t *testing.T) {
 	utils.AssertEqual(t, c.fasthttp.Response.StatusCode(), StatusBadGateway)
 }
 
+func Test_App_serverErrorHandler_Concurrency_Limit(t *testing.T) {
+	t.Parallel()
+	app := New()
+	c := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(c)
+	app.serverErrorHandler(c.fasthttp, fasthttp.ErrConcurrencyLimit)
+	utils.AssertEqual(t, utils.StatusMessage(StatusServiceUnavailable), string(c.fasthttp.Response.Body()))
+	utils.AssertEqual(t, StatusServiceUnavailable, c.fasthttp.Response.StatusCode())
+	utils.AssertEqual(t, "1", string(c.fasthttp.Response.Header.Peek(HeaderRetryAfter)))
+}
+
+// go test -run Test_App_serverErrorHandler_HeaderLimitHandler
+func Test_App_serverErrorHandler_HeaderLimitHandler(t *testing.T) {
+	t.Parallel()
+	app := New(Config{
+		HeaderLimitHandler: func(c *Ctx) error {
+			return c.Status(StatusRequestHeaderFieldsTooLarge).
+				SendString("headers too large, trim your cookies")
+		},
+	})
+	c := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(c)
+	app.serverErrorHandler(c.fasthttp, &fasthttp.ErrSmallBuffer{})
+	utils.AssertEqual(t, "headers too large, trim your cookies", string(c.fasthttp.Response.Body()))
+	utils.AssertEqual(t, StatusRequestHeaderFieldsTooLarge, c.fasthttp.Response.StatusCode())
+
+	// a failing hook falls back to the regular mapping
+	app = New(Config{
+		HeaderLimitHandler: func(c *Ctx) error {
+			return errors.New("hook failed")
+		},
+	})
+	c = app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(c)
+	app.serverErrorHandler(c.fasthttp, &fasthttp.ErrSmallBuffer{})
+	utils.AssertEqual(t, utils.StatusMessage(StatusRequestHeaderFieldsTooLarge), string(c.fasthttp.Response.Body()))
+	utils.AssertEqual(t, StatusRequestHeaderFieldsTooLarge, c.fasthttp.Response.StatusCode())
+}
+
 func Test_App_Nested_Params(t *testing.T) {
 	t.Parallel()
 	app := New()
@@ -349,6 +391,42 @@ func Test_App_Use_Params(t *testing.T) {
 	})
 }
 
+// go test -run Test_App_UseFirst
+func Test_App_UseFirst(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	var order []string
+	app.Use("/api", func(c *Ctx) error {
+		order = append(order, "second")
+		return c.Next()
+	})
+	app.Get("/api/users", func(c *Ctx) error {
+		order = append(order, "handler")
+		return nil
+	})
+	app.UseFirst("/api", func(c *Ctx) error {
+		order = append(order, "first")
+		return c.Next()
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/api/users", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, 200, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, "first,second,handler", strings.Join(order, ","))
+
+	// a later UseFirst runs before the earlier one
+	app.UseFirst("/api", func(c *Ctx) error {
+		order = append(order, "zero")
+		return c.Next()
+	})
+	order = order[:0]
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/api/users", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, 200, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, "zero,first,second,handler", strings.Join(order, ","))
+}
+
 func Test_App_Use_UnescapedPath(t *testing.T) {
 	t.Parallel()
 	app := New(Config{UnescapePath: true, CaseSensitive: true})
@@ -1800,6 +1878,53 @@ func TestApp_GetRoutes(t *testing.T) {
 	}
 }
 
+// go test -run Test_App_Name_Duplicate
+func Test_App_Name_Duplicate(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.Get("/first", testEmptyHandler).Name("unique")
+	// renaming the same route is fine
+	app.Get("/first", testEmptyHandler).Name("unique")
+
+	defer func() {
+		utils.AssertEqual(t,
+			"name: duplicate route name unique for paths /first and /second\n",
+			fmt.Sprintf("%v", recover()))
+	}()
+	app.Get("/second", testEmptyHandler).Name("unique")
+}
+
+// go test -run Test_App_ExportRoutes
+func Test_App_ExportRoutes(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Use(func(c *Ctx) error {
+		return c.Next()
+	})
+	app.Post("/users/:id", testEmptyHandler).Name("user.update")
+
+	data, err := app.ExportRoutes()
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, nil, ValidateExportedRoutes(data))
+	// handler functions are not portable and must not leak into the export
+	utils.AssertEqual(t, false, strings.Contains(strings.ToLower(string(data)), "handler"))
+
+	var routes []Route
+	utils.AssertEqual(t, nil, json.Unmarshal(data, &routes))
+	// middleware is filtered, only POST remains
+	utils.AssertEqual(t, 1, len(routes))
+	utils.AssertEqual(t, MethodPost, routes[0].Method)
+	utils.AssertEqual(t, "/users/:id", routes[0].Path)
+	utils.AssertEqual(t, "user.update", routes[0].Name)
+	utils.AssertEqual(t, []string{"id"}, routes[0].Params)
+	utils.AssertEqual(t, 0, len(routes[0].Handlers))
+
+	utils.AssertEqual(t, true, ValidateExportedRoutes([]byte("{")) != nil)
+	utils.AssertEqual(t, true, ValidateExportedRoutes([]byte(`[{"method":"GET","path":"no-slash"}]`)) != nil)
+	utils.AssertEqual(t, true, ValidateExportedRoutes([]byte(`[{"path":"/ok"}]`)) != nil)
+}
+
 func Test_Middleware_Route_Naming_With_Use(t *testing.T) {
 	named := "named"
 	app := New()
@@ -1906,8 +2031,8 @@ func Test_Route_Naming_Issue_2671_2685(t *testing.T) {
 	utils.AssertEqual(t, "/post/:postId", app.GetRoute("post.update").Path)
 
 	// Add testcase for routes use the same PATH on different methods
-	app.Get("/users", nil).Name("get-users")
-	app.Post("/users", nil).Name("add-user")
+	app.Get("/users", emptyHandler).Name("get-users")
+	app.Post("/users", emptyHandler).Name("add-user")
 	getUsers := app.GetRoute("get-users")
 	utils.AssertEqual(t, getUsers.Path, "/users")
 
@@ -1916,8 +2041,8 @@ func Test_Route_Naming_Issue_2671_2685(t *testing.T) {
 
 	// Add testcase for routes use the same PATH on different methods (for groups)
 	newGrp := app.Group("/name-test")
-	newGrp.Get("/users", nil).Name("grp-get-users")
-	newGrp.Post("/users", nil).Name("grp-add-user")
+	newGrp.Get("/users", emptyHandler).Name("grp-get-users")
+	newGrp.Post("/users", emptyHandler).Name("grp-add-user")
 	getUsers = app.GetRoute("grp-get-users")
 	utils.AssertEqual(t, getUsers.Path, "/name-test/users")
 
@@ -1934,3 +2059,1517 @@ func Test_Route_Naming_Issue_2671_2685(t *testing.T) {
 	sRoute2 := app.GetRoute("simple-route2")
 	utils.AssertEqual(t, sRoute2.Path, "/simple-route")
 }
+
+func Test_App_RoutesHash(t *testing.T) {
+	t.Parallel()
+
+	app1 := New()
+	app1.Get("/foo", emptyHandler)
+	app1.Post("/bar", emptyHandler)
+
+	// same routes, registered in a different order
+	app2 := New()
+	app2.Post("/bar", emptyHandler)
+	app2.Get("/foo", emptyHandler)
+
+	utils.AssertEqual(t, app1.RoutesHash(), app2.RoutesHash())
+
+	// adding a route changes the hash
+	app2.Get("/baz/:id", emptyHandler)
+	utils.AssertEqual(t, false, app1.RoutesHash() == app2.RoutesHash())
+
+	// hashing is idempotent
+	utils.AssertEqual(t, app2.RoutesHash(), app2.RoutesHash())
+}
+
+type captureServerLogger struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (l *captureServerLogger) Printf(format string, args ...interface{}) {
+	l.mu.Lock()
+	l.logs = append(l.logs, fmt.Sprintf(format, args...))
+	l.mu.Unlock()
+}
+
+func Test_App_Config_Logger(t *testing.T) {
+	t.Parallel()
+
+	// by default server-level logs are discarded
+	app := New()
+	_, ok := app.server.Logger.(*disableLogger)
+	utils.AssertEqual(t, true, ok)
+
+	// a configured logger receives server-level errors
+	logger := &captureServerLogger{}
+	app = New(Config{Logger: logger, Concurrency: 1, DisableStartupMessage: true})
+	app.Get("/", testEmptyHandler)
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() {
+		utils.AssertEqual(t, nil, app.Listener(ln))
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+
+	// the first connection occupies the only concurrency slot ...
+	conn1, err := net.Dial(NetworkTCP4, ln.Addr().String())
+	utils.AssertEqual(t, nil, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	// ... so the second one is rejected and the rejection is logged
+	conn2, err := net.Dial(NetworkTCP4, ln.Addr().String())
+	utils.AssertEqual(t, nil, err)
+	_, _ = io.ReadAll(conn2)
+	utils.AssertEqual(t, nil, conn2.Close())
+
+	utils.AssertEqual(t, nil, conn1.Close())
+	utils.AssertEqual(t, nil, app.ShutdownWithTimeout(time.Second))
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	utils.AssertEqual(t, true, len(logger.logs) > 0, "expected a server-level error to be logged")
+	utils.AssertEqual(t, true, strings.Contains(logger.logs[0], "concurrent connections"), logger.logs[0])
+}
+
+func Test_App_AllowedMethods(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Use(func(c *Ctx) error {
+		return c.Next()
+	})
+	app.Get("/users", testEmptyHandler)
+	app.Post("/users", testEmptyHandler)
+	app.Put("/*", testEmptyHandler)
+
+	// Get also registers a HEAD route, middleware is excluded
+	utils.AssertEqual(t, []string{MethodGet, MethodHead, MethodPost, MethodPut}, app.AllowedMethods("/users"))
+
+	// only the wildcard matches everything else
+	utils.AssertEqual(t, []string{MethodPut}, app.AllowedMethods("/everything/else"))
+
+	// no match at all
+	noWildcard := New()
+	noWildcard.Post("/only", testEmptyHandler)
+	utils.AssertEqual(t, 0, len(noWildcard.AllowedMethods("/missing")))
+}
+
+func Test_App_ParamNames(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Use(func(c *Ctx) error {
+		return c.Next()
+	})
+	app.Get("/users/:id/books/:title", testEmptyHandler)
+	app.Get("/plain", testEmptyHandler)
+
+	// param names come back in declaration order, middleware is excluded
+	utils.AssertEqual(t, []string{"id", "title"}, app.ParamNames(MethodGet, "/users/42/books/fiber"))
+
+	// matched routes without params and unmatched paths both yield nil
+	utils.AssertEqual(t, 0, len(app.ParamNames(MethodGet, "/plain")))
+	utils.AssertEqual(t, 0, len(app.ParamNames(MethodGet, "/missing")))
+	utils.AssertEqual(t, 0, len(app.ParamNames("FOO", "/users/42/books/fiber")))
+}
+
+type validationError struct {
+	field string
+}
+
+func (e *validationError) Error() string {
+	return "invalid field " + e.field
+}
+
+// go test -run Test_App_OnErrorType
+func Test_App_OnErrorType(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.OnErrorType(&validationError{}, func(c *Ctx, err error) error {
+		return c.Status(StatusUnprocessableEntity).SendString(err.Error())
+	})
+	app.Get("/invalid", func(c *Ctx) error {
+		return &validationError{field: "name"}
+	})
+	app.Get("/wrapped", func(c *Ctx) error {
+		return fmt.Errorf("saving user: %w", &validationError{field: "age"})
+	})
+	app.Get("/other", func(c *Ctx) error {
+		return errors.New("boom")
+	})
+
+	// the registered type routes to its handler, directly and wrapped
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/invalid", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusUnprocessableEntity, resp.StatusCode, "Status code")
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/wrapped", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusUnprocessableEntity, resp.StatusCode, "Status code")
+
+	// unknown error types keep going through the default handler
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/other", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusInternalServerError, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_App_EnforceUnambiguousRoutes
+func Test_App_EnforceUnambiguousRoutes(t *testing.T) {
+	t.Parallel()
+
+	// a clearly-ordered specific+wildcard pair passes startup
+	app := New()
+	app.EnforceUnambiguousRoutes()
+	app.Get("/users/list", testEmptyHandler)
+	app.Get("/users/*", testEmptyHandler)
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/users/list", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// two params with equal specificity on the same method fail it
+	app = New()
+	app.EnforceUnambiguousRoutes()
+	app.Get("/users/:id", testEmptyHandler)
+	app.Get("/users/:name", testEmptyHandler)
+	defer func() {
+		utils.AssertEqual(t,
+			"strict routes: GET /users/:id and /users/:name overlap with equal specificity",
+			fmt.Sprintf("%v", recover()))
+	}()
+	app.startupProcess()
+}
+
+func Test_App_EmptyResponseMode(t *testing.T) {
+	t.Parallel()
+
+	setup := func(config ...Config) *App {
+		app := New(config...)
+		app.Get("/noop", func(c *Ctx) error {
+			return nil
+		})
+		app.Get("/written", func(c *Ctx) error {
+			return c.SendString("ok")
+		})
+		return app
+	}
+
+	// default keeps the empty 200
+	resp, err := setup().Test(httptest.NewRequest(MethodGet, "/noop", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// no-content mode rewrites the untouched response into a 204 ...
+	app := setup(Config{EmptyResponseMode: EmptyResponseNoContent})
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/noop", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusNoContent, resp.StatusCode, "Status code")
+
+	// ... while handlers that wrote something are left alone
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/written", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// error mode flags the silent handler as a programming error
+	app = setup(Config{EmptyResponseMode: EmptyResponseError})
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/noop", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusInternalServerError, resp.StatusCode, "Status code")
+}
+
+func Test_App_ReadBodyStream_BodyLimit(t *testing.T) {
+	t.Parallel()
+	app := New(Config{StreamRequestBody: true, BodyLimit: 10})
+	app.Post("/", func(c *Ctx) error {
+		// Calling c.Body() automatically reads the entire stream.
+		return c.SendString(fmt.Sprintf("%s", c.Body()))
+	})
+
+	// bodies within the limit are streamed to the handler
+	resp, err := app.Test(httptest.NewRequest(MethodPost, "/", bytes.NewBufferString("small")))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// bodies exceeding the limit are rejected instead of read indefinitely
+	resp, err = app.Test(httptest.NewRequest(MethodPost, "/", bytes.NewBufferString(strings.Repeat("x", 11))))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusRequestEntityTooLarge, resp.StatusCode, "Status code")
+}
+
+func Test_App_Prewarm(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Get("/foo", testEmptyHandler)
+	app.Post("/bar/:id", testEmptyHandler)
+
+	utils.AssertEqual(t, true, app.routesRefreshed)
+
+	app.Prewarm()
+	utils.AssertEqual(t, false, app.routesRefreshed)
+	utils.AssertEqual(t, true, len(app.getTreeStack()[app.methodInt(MethodGet)]) > 0)
+
+	// calling it again is a no-op
+	app.Prewarm()
+	utils.AssertEqual(t, false, app.routesRefreshed)
+
+	// the app still serves requests as usual
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/foo", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
+func Test_App_Resource(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Resource("/x", map[string]Handler{
+		MethodGet: func(c *Ctx) error {
+			return c.SendString("get")
+		},
+		MethodPost: func(c *Ctx) error {
+			return c.SendString("post")
+		},
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/x", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "get", string(body))
+
+	resp, err = app.Test(httptest.NewRequest(MethodPost, "/x", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "post", string(body))
+
+	// unspecified methods get a 405 with the Allow header listing the map keys
+	resp, err = app.Test(httptest.NewRequest(MethodDelete, "/x", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusMethodNotAllowed, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, true, strings.Contains(resp.Header.Get(HeaderAllow), MethodGet))
+	utils.AssertEqual(t, true, strings.Contains(resp.Header.Get(HeaderAllow), MethodPost))
+}
+
+func Test_AdaptContextHandler(t *testing.T) {
+	t.Parallel()
+
+	app := New(Config{DisableStartupMessage: true})
+	canceled := make(chan struct{})
+	app.Get("/slow", AdaptContextHandler(func(ctx context.Context, c *Ctx) error {
+		select {
+		case <-ctx.Done():
+			close(canceled)
+			return nil
+		case <-time.After(3 * time.Second):
+			return c.SendString("too late")
+		}
+	}))
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() {
+		utils.AssertEqual(t, nil, app.Listener(ln))
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+
+	conn, err := net.Dial(NetworkTCP4, ln.Addr().String())
+	utils.AssertEqual(t, nil, err)
+	_, err = conn.Write([]byte("GET /slow HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	utils.AssertEqual(t, nil, err)
+
+	time.Sleep(100 * time.Millisecond)
+	utils.AssertEqual(t, nil, conn.Close())
+
+	// closing the connection must cancel the context seen by the handler
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("context was not canceled on client disconnect")
+	}
+
+	utils.AssertEqual(t, nil, app.ShutdownWithTimeout(time.Second))
+}
+
+func Test_AdaptContextHandler_StreamedBody(t *testing.T) {
+	t.Parallel()
+
+	// with body streaming the disconnect probe must stay off the connection,
+	// a probe read would steal a body byte and cancel the context for a
+	// connected client
+	app := New(Config{
+		StreamRequestBody:     true,
+		DisableStartupMessage: true,
+	})
+	app.Post("/echo", AdaptContextHandler(func(ctx context.Context, c *Ctx) error {
+		body, err := io.ReadAll(c.BodyStream())
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return c.Send(body)
+	}))
+
+	resp, err := app.Test(httptest.NewRequest(MethodPost, "/echo", bytes.NewBufferString("payload")))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "payload", string(body))
+}
+
+func Test_App_ErrorHandler_Failure_Committed_Response(t *testing.T) {
+	t.Parallel()
+
+	app := New(Config{
+		ErrorHandler: func(c *Ctx, _ error) error {
+			// write partial output, then fail
+			_, _ = c.WriteString("partial")
+			return errors.New("error handler failed")
+		},
+	})
+	app.Get("/", func(_ *Ctx) error {
+		return errors.New("handler error")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+
+	// the partial output must not be overridden with a forced 500
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "partial", string(body))
+}
+
+func Test_App_ErrorHandler_Failure_Uncommitted_Response(t *testing.T) {
+	t.Parallel()
+
+	app := New(Config{
+		ErrorHandler: func(_ *Ctx, _ error) error {
+			return errors.New("error handler failed")
+		},
+	})
+	app.Get("/", func(_ *Ctx) error {
+		return errors.New("handler error")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusInternalServerError, resp.StatusCode, "Status code")
+}
+
+func Test_App_Fallback(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Get("/known", testEmptyHandler)
+	app.Fallback(func(c *Ctx) error {
+		return c.Status(StatusOK).SendString("fallback " + c.Method() + " " + c.Path())
+	})
+
+	// unmatched paths are handled by the fallback instead of a 404
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/unknown", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "fallback GET /unknown", string(body))
+
+	// the 405 determination still wins over the fallback
+	resp, err = app.Test(httptest.NewRequest(MethodPost, "/known", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusMethodNotAllowed, resp.StatusCode, "Status code")
+}
+
+func Test_App_Head_ContentLength(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	// handler shared between GET and HEAD that computes a body
+	app.Get("/full", func(c *Ctx) error {
+		return c.SendString("hello world")
+	})
+	// handler that skips body generation for HEAD but announces the size
+	app.Get("/skip", func(c *Ctx) error {
+		if c.Method() == MethodHead {
+			c.Set(HeaderContentLength, "11")
+			return nil
+		}
+		return c.SendString("hello world")
+	})
+
+	// the body is stripped but Content-Length reflects the would-be body
+	resp, err := app.Test(httptest.NewRequest(MethodHead, "/full", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, "11", resp.Header.Get(HeaderContentLength))
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, 0, len(body))
+
+	// an explicitly set Content-Length survives an empty HEAD body
+	resp, err = app.Test(httptest.NewRequest(MethodHead, "/skip", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, "11", resp.Header.Get(HeaderContentLength))
+}
+
+func Test_App_Server_MaxConnsPerIP(t *testing.T) {
+	t.Parallel()
+
+	app := New(Config{DisableStartupMessage: true})
+	app.Get("/", testEmptyHandler)
+	app.Server().MaxConnsPerIP = 1
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() {
+		utils.AssertEqual(t, nil, app.Listener(ln))
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+
+	// the first connection from this ip takes the only slot ...
+	conn1, err := net.Dial(NetworkTCP4, ln.Addr().String())
+	utils.AssertEqual(t, nil, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	// ... so the second one is rejected by the per-ip limit
+	conn2, err := net.Dial(NetworkTCP4, ln.Addr().String())
+	utils.AssertEqual(t, nil, err)
+	out, err := io.ReadAll(conn2)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, true, strings.Contains(string(out), "exceeds MaxConnsPerIP"), string(out))
+	utils.AssertEqual(t, nil, conn2.Close())
+
+	utils.AssertEqual(t, nil, conn1.Close())
+	utils.AssertEqual(t, nil, app.ShutdownWithTimeout(time.Second))
+}
+
+// go test -run Test_App_EnableRouteIntrospectionEndpoint
+func Test_App_EnableRouteIntrospectionEndpoint(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/users/:id", testEmptyHandler)
+	app.Post("/users", testEmptyHandler)
+	app.EnableRouteIntrospectionEndpoint("/_routes")
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/_routes", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, MIMEApplicationJSON, resp.Header.Get(HeaderContentType))
+
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+
+	var routes []routeIntrospection
+	utils.AssertEqual(t, nil, json.Unmarshal(body, &routes))
+
+	found := map[string]routeIntrospection{}
+	for _, route := range routes {
+		found[route.Method+" "+route.Path] = route
+	}
+
+	userRoute, ok := found["GET /users/:id"]
+	utils.AssertEqual(t, true, ok)
+	utils.AssertEqual(t, []string{"id"}, userRoute.Params)
+	utils.AssertEqual(t, 1, userRoute.Handlers)
+
+	_, ok = found["POST /users"]
+	utils.AssertEqual(t, true, ok)
+
+	// the endpoint lists itself
+	_, ok = found["GET /_routes"]
+	utils.AssertEqual(t, true, ok)
+}
+
+// go test -run Test_App_EnableRouteIntrospectionEndpoint_Guarded
+func Test_App_EnableRouteIntrospectionEndpoint_Guarded(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.EnableRouteIntrospectionEndpoint("/_routes", func(c *Ctx) error {
+		if c.Get(HeaderAuthorization) == "" {
+			return ErrUnauthorized
+		}
+		return c.Next()
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/_routes", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusUnauthorized, resp.StatusCode, "Status code")
+
+	req := httptest.NewRequest(MethodGet, "/_routes", nil)
+	req.Header.Set(HeaderAuthorization, "token")
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_Config_Validate
+func Test_Config_Validate(t *testing.T) {
+	t.Parallel()
+
+	utils.AssertEqual(t, nil, Config{}.Validate())
+	utils.AssertEqual(t, nil, Config{BodyLimit: 1024, Concurrency: 1, ReadTimeout: time.Second}.Validate())
+	utils.AssertEqual(t, nil, Config{BodyLimit: -1}.Validate())
+
+	testCases := []Config{
+		{BodyLimit: -2},
+		{Concurrency: -1},
+		{ReadBufferSize: -1},
+		{WriteBufferSize: -1},
+		{ReadTimeout: -time.Second},
+		{WriteTimeout: -time.Second},
+		{IdleTimeout: -time.Second},
+	}
+	for _, cfg := range testCases {
+		utils.AssertEqual(t, false, cfg.Validate() == nil)
+	}
+}
+
+// go test -run Test_Config_Validate_New_Panics
+func Test_Config_Validate_New_Panics(t *testing.T) {
+	t.Parallel()
+	defer func() {
+		utils.AssertEqual(t, false, recover() == nil)
+	}()
+
+	New(Config{Concurrency: -1})
+}
+
+// go test -run Test_App_AddRoutes
+func Test_App_AddRoutes(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	errs := app.AddRoutes([]RouteSpec{
+		{Method: MethodGet, Path: "/a", Name: "a", Handlers: []Handler{testEmptyHandler}},
+		{Method: MethodPost, Path: "/b", Handlers: []Handler{testEmptyHandler}},
+		{Method: "get", Path: "/c", Middleware: []Handler{func(c *Ctx) error { return c.Next() }}, Handlers: []Handler{func(c *Ctx) error {
+			return c.SendString("c")
+		}}},
+	})
+	utils.AssertEqual(t, 0, len(errs))
+
+	utils.AssertEqual(t, "/a", app.GetRoute("a").Path)
+
+	// registration order is preserved
+	var paths []string
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == MethodGet {
+			paths = append(paths, route.Path)
+		}
+	}
+	utils.AssertEqual(t, []string{"/a", "/c"}, paths)
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/c", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_App_AddRoutes_Invalid
+func Test_App_AddRoutes_Invalid(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	errs := app.AddRoutes([]RouteSpec{
+		{Method: MethodGet, Path: "/ok", Handlers: []Handler{testEmptyHandler}},
+		{Method: "SPY", Path: "/bad-method", Handlers: []Handler{testEmptyHandler}},
+		{Method: MethodGet, Path: "/no-handlers"},
+		{Method: MethodGet, Path: "/nil-handler", Handlers: []Handler{nil}},
+	})
+	utils.AssertEqual(t, 3, len(errs))
+
+	// a partial failure leaves the route table untouched
+	utils.AssertEqual(t, 0, len(app.GetRoutes(true)))
+}
+
+// go test -run Test_App_RecoverHandler
+func Test_App_RecoverHandler(t *testing.T) {
+	t.Parallel()
+	app := New(Config{
+		RecoverHandler: func(c *Ctx, recovered interface{}) error {
+			return NewError(StatusInternalServerError, fmt.Sprintf("recovered: %v", recovered))
+		},
+	})
+	app.Get("/panic", func(c *Ctx) error {
+		panic("boom")
+	})
+	app.Get("/ok", func(c *Ctx) error {
+		return c.SendString("ok")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/panic", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusInternalServerError, resp.StatusCode, "Status code")
+
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "recovered: boom", string(body))
+
+	// the Ctx was released back into the pool and the app keeps serving
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/ok", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "ok", string(body))
+}
+
+// go test -run Test_App_ServerHeader_Combinations
+func Test_App_ServerHeader_Combinations(t *testing.T) {
+	t.Parallel()
+
+	// custom header
+	app := New(Config{ServerHeader: "TestServer"})
+	app.Get("/", testEmptyHandler)
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, "TestServer", resp.Header.Get(HeaderServer))
+
+	// fasthttp's default header when nothing is configured
+	app = New()
+	app.Get("/", testEmptyHandler)
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, "fasthttp", resp.Header.Get(HeaderServer))
+
+	// no header at all, even with a custom one configured
+	app = New(Config{ServerHeader: "TestServer", DisableServerHeader: true})
+	app.Get("/", testEmptyHandler)
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, "", resp.Header.Get(HeaderServer))
+}
+
+// go test -run Test_App_Clone
+func Test_App_Clone(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/original", func(c *Ctx) error {
+		return c.SendString("original")
+	})
+
+	clone := app.Clone()
+
+	// the clone serves the original's routes
+	resp, err := clone.Test(httptest.NewRequest(MethodGet, "/original", nil))
+	utils.AssertEqual(t, nil, err, "clone.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// routes added to the clone do not leak into the original
+	clone.Get("/extra", testEmptyHandler)
+
+	resp, err = clone.Test(httptest.NewRequest(MethodGet, "/extra", nil))
+	utils.AssertEqual(t, nil, err, "clone.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/extra", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode, "Status code")
+
+	// appending handlers to a cloned route's chain stays private as well
+	clone.Get("/original", testEmptyHandler)
+	var handlers int
+	for _, route := range app.GetRoutes(true) {
+		if route.Path == "/original" && route.Method == MethodGet {
+			handlers = len(route.Handlers)
+		}
+	}
+	utils.AssertEqual(t, 1, handlers)
+}
+
+// go test -run Test_App_ClientDisconnect_TruncatedBody
+func Test_App_ClientDisconnect_TruncatedBody(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		caught   error
+		handlers int
+	)
+	app := New(Config{
+		StreamRequestBody:     true,
+		DisableStartupMessage: true,
+		ErrorHandler: func(c *Ctx, err error) error {
+			mu.Lock()
+			caught = err
+			handlers++
+			mu.Unlock()
+			return DefaultErrorHandler(c, err)
+		},
+	})
+	app.Post("/upload", func(c *Ctx) error {
+		_, err := io.ReadAll(c.BodyStream())
+		if err != nil {
+			return err
+		}
+		return c.SendString("done")
+	})
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() {
+		utils.AssertEqual(t, nil, app.Listener(ln))
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// announce more body bytes than are sent, then hang up
+	conn, err := net.Dial(NetworkTCP4, ln.Addr().String())
+	utils.AssertEqual(t, nil, err)
+	_, err = conn.Write([]byte("POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: 100\r\n\r\nabc"))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, nil, conn.Close())
+	time.Sleep(500 * time.Millisecond)
+
+	mu.Lock()
+	utils.AssertEqual(t, ErrClientDisconnected, caught)
+	utils.AssertEqual(t, true, handlers >= 1)
+	mu.Unlock()
+
+	utils.AssertEqual(t, nil, app.ShutdownWithTimeout(time.Second))
+}
+
+// go test -run Test_App_ClientDisconnect_SkipErrorHandler
+func Test_App_ClientDisconnect_SkipErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		handlers int
+	)
+	app := New(Config{
+		StreamRequestBody:            true,
+		DisableStartupMessage:        true,
+		SkipErrorHandlerOnDisconnect: true,
+		ErrorHandler: func(c *Ctx, err error) error {
+			mu.Lock()
+			handlers++
+			mu.Unlock()
+			return DefaultErrorHandler(c, err)
+		},
+	})
+	app.Post("/upload", func(c *Ctx) error {
+		_, err := io.ReadAll(c.BodyStream())
+		if err != nil {
+			return err
+		}
+		return c.SendString("done")
+	})
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() {
+		utils.AssertEqual(t, nil, app.Listener(ln))
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial(NetworkTCP4, ln.Addr().String())
+	utils.AssertEqual(t, nil, err)
+	_, err = conn.Write([]byte("POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: 100\r\n\r\nabc"))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, nil, conn.Close())
+	time.Sleep(500 * time.Millisecond)
+
+	mu.Lock()
+	utils.AssertEqual(t, 0, handlers)
+	mu.Unlock()
+
+	utils.AssertEqual(t, nil, app.ShutdownWithTimeout(time.Second))
+}
+
+// go test -run Test_App_ClientDisconnect_WrappedEOFNotDisconnect
+func Test_App_ClientDisconnect_WrappedEOFNotDisconnect(t *testing.T) {
+	t.Parallel()
+
+	var caught error
+	app := New(Config{
+		SkipErrorHandlerOnDisconnect: true,
+		ErrorHandler: func(c *Ctx, err error) error {
+			caught = err
+			return DefaultErrorHandler(c, err)
+		},
+	})
+	// a handler error wrapping io.EOF, e.g. from a truncated upstream read,
+	// is not a client disconnect and must reach the error handler
+	app.Get("/proxy", func(c *Ctx) error {
+		return fmt.Errorf("upstream read failed: %w", io.EOF)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/proxy", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusInternalServerError, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, false, caught == nil)
+	utils.AssertEqual(t, true, errors.Is(caught, io.EOF))
+}
+
+// go test -run Test_App_LastTreeBuildDuration
+func Test_App_LastTreeBuildDuration(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	for i := 0; i < 500; i++ {
+		app.Get("/route/"+strconv.Itoa(i), testEmptyHandler)
+	}
+	utils.AssertEqual(t, time.Duration(0), app.LastTreeBuildDuration())
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/route/42", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, true, app.LastTreeBuildDuration() > 0, "duration should be recorded after startup")
+}
+
+// go test -run Test_App_RebuildTreeAsync
+func Test_App_RebuildTreeAsync(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/old", testEmptyHandler)
+	app.startupProcess()
+	handler := app.Handler()
+
+	request := func(path string) int {
+		fctx := &fasthttp.RequestCtx{}
+		fctx.Request.Header.SetMethod(MethodGet)
+		fctx.URI().SetPath(path)
+		handler(fctx)
+		return fctx.Response.StatusCode()
+	}
+	utils.AssertEqual(t, StatusOK, request("/old"))
+
+	// routes registered after startup are invisible until the next rebuild
+	app.Get("/new", testEmptyHandler)
+
+	// hammer the old route while the rebuild runs in the background, no
+	// request may miss it
+	stop := make(chan struct{})
+	misses := make(chan int, 1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		count := 0
+		for {
+			select {
+			case <-stop:
+				misses <- count
+				return
+			default:
+			}
+			if request("/old") != StatusOK {
+				count++
+			}
+		}
+	}()
+
+	utils.AssertEqual(t, nil, <-app.RebuildTreeAsync())
+	close(stop)
+	wg.Wait()
+	utils.AssertEqual(t, 0, <-misses)
+	// the swapped-in tree carries the late-registered route
+	utils.AssertEqual(t, StatusOK, request("/new"))
+}
+
+// go test -run Test_App_OnRouteAdd
+func Test_App_OnRouteAdd(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	count := 0
+	app.OnRouteAdd(func(*Route) { count++ })
+
+	// Get registers the paired HEAD route as well
+	app.Get("/a", testEmptyHandler)
+	app.Post("/b", testEmptyHandler)
+	utils.AssertEqual(t, 3, count)
+
+	// merged into the existing GET route, no new route enters the stack
+	app.Add(MethodGet, "/a", testEmptyHandler)
+	utils.AssertEqual(t, 3, count)
+
+	// middleware is copied into every method stack, one callback per copy
+	app.Use(testEmptyHandler)
+	utils.AssertEqual(t, 3+len(app.config.RequestMethods), count)
+}
+
+// go test -run Test_App_OnRouteAdd_Mount
+func Test_App_OnRouteAdd_Mount(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	count := 0
+	app.OnRouteAdd(func(*Route) { count++ })
+
+	sub := New()
+	sub.Get("/child", testEmptyHandler)
+
+	// the mount placeholder is registered like middleware, once per method
+	app.Mount("/sub", sub)
+	utils.AssertEqual(t, len(app.config.RequestMethods), count)
+
+	// pulling the sub-app's routes at startup imports its GET and paired
+	// HEAD route into the parent's stack
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/sub/child", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, len(app.config.RequestMethods)+2, count)
+}
+
+// go test -run Test_App_MethodCaseInsensitive
+func Test_App_MethodCaseInsensitive(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	var method string
+	app.Get("/x", func(c *Ctx) error {
+		method = c.Method()
+		return nil
+	})
+
+	// lowercase methods are aliased to their registered form
+	resp, err := app.Test(httptest.NewRequest("get", "/x", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, MethodGet, method)
+
+	// methods still unknown after uppercasing stay invalid
+	resp, err = app.Test(httptest.NewRequest("gibberish", "/x", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusBadRequest, resp.StatusCode)
+}
+
+// go test -run Test_App_NegotiateErrors
+func Test_App_NegotiateErrors(t *testing.T) {
+	t.Parallel()
+	app := New(Config{NegotiateErrors: true})
+
+	app.Get("/only-get", testEmptyHandler)
+
+	// API clients get JSON
+	req := httptest.NewRequest(MethodGet, "/missing", nil)
+	req.Header.Set(HeaderAccept, MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+	utils.AssertEqual(t, MIMEApplicationJSONCharsetUTF8, resp.Header.Get(HeaderContentType))
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, `{"status":404,"error":"Cannot GET /missing"}`, string(body))
+
+	// browsers get HTML
+	req = httptest.NewRequest(MethodGet, "/missing", nil)
+	req.Header.Set(HeaderAccept, MIMETextHTML)
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+	utils.AssertEqual(t, MIMETextHTMLCharsetUTF8, resp.Header.Get(HeaderContentType))
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, true, strings.Contains(string(body), "<h1>404</h1>"))
+
+	// 405 is negotiated as well
+	req = httptest.NewRequest(MethodPost, "/only-get", nil)
+	req.Header.Set(HeaderAccept, MIMEApplicationJSON)
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusMethodNotAllowed, resp.StatusCode)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, `{"status":405,"error":"Method Not Allowed"}`, string(body))
+
+	// everything else keeps the plain-text default
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/missing", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+	utils.AssertEqual(t, MIMETextPlainCharsetUTF8, resp.Header.Get(HeaderContentType))
+}
+
+// go test -run Test_App_NegotiateErrors_CustomTemplate
+func Test_App_NegotiateErrors_CustomTemplate(t *testing.T) {
+	t.Parallel()
+	app := New(Config{
+		NegotiateErrors: true,
+		ErrorTemplates: map[string]string{
+			MIMEApplicationJSON: `{"oops":%code%}`,
+		},
+	})
+
+	req := httptest.NewRequest(MethodGet, "/missing", nil)
+	req.Header.Set(HeaderAccept, MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, `{"oops":404}`, string(body))
+}
+
+// go test -run Test_App_AddRewrite
+func Test_App_AddRewrite(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.AddRewrite("/old/:id", "/new/:id")
+
+	app.Get("/new/:id", func(c *Ctx) error {
+		return c.SendString(c.Params("id") + "|" + c.OriginalURL())
+	})
+
+	// internal rewrite serves the target route under the original URL
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/old/42", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "42|/old/42", string(body))
+
+	// the target route stays reachable directly
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/new/7", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "7|/new/7", string(body))
+}
+
+// go test -run Test_App_AddRedirectRewrite
+func Test_App_AddRedirectRewrite(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.AddRedirectRewrite("/legacy/:name", "/current/:name")
+
+	app.Get("/current/:name", testEmptyHandler)
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/legacy/report", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusMovedPermanently, resp.StatusCode)
+	utils.AssertEqual(t, "/current/report", resp.Header.Get(HeaderLocation))
+}
+
+// go test -run Test_App_MiddlewareOverlaps
+func Test_App_MiddlewareOverlaps(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.Use("/api", func(c *Ctx) error { return c.Next() })
+	app.Use("/api/v1", func(c *Ctx) error { return c.Next() })
+	app.Use("/admin", func(c *Ctx) error { return c.Next() })
+	// a string prefix but not a path-boundary one, no overlap
+	app.Use("/apiary", func(c *Ctx) error { return c.Next() })
+
+	overlaps := app.MiddlewareOverlaps()
+	utils.AssertEqual(t, 1, len(overlaps))
+	utils.AssertEqual(t, 2, len(overlaps[0]))
+	utils.AssertEqual(t, "/api", overlaps[0][0].Path)
+	utils.AssertEqual(t, "/api/v1", overlaps[0][1].Path)
+
+	// no middleware overlaps at all
+	clean := New()
+	clean.Use("/a", func(c *Ctx) error { return c.Next() })
+	clean.Use("/b", func(c *Ctx) error { return c.Next() })
+	utils.AssertEqual(t, 0, len(clean.MiddlewareOverlaps()))
+}
+
+// go test -run Test_App_TrailingSlashHandler
+func Test_App_TrailingSlashHandler(t *testing.T) {
+	t.Parallel()
+	app := New(Config{
+		StrictRouting: true,
+		TrailingSlashHandler: func(c *Ctx) error {
+			return c.Redirect(utils.TrimRight(c.Path(), '/'), StatusPermanentRedirect)
+		},
+	})
+
+	app.Get("/foo", testEmptyHandler)
+
+	// the canonical form matches normally
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/foo", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	// a miss caused only by the trailing slash triggers the handler
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/foo/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusPermanentRedirect, resp.StatusCode)
+	utils.AssertEqual(t, "/foo", resp.Header.Get(HeaderLocation))
+
+	// misses with no slashless counterpart stay plain 404s
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/bar/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+}
+
+// go test -run Test_App_SetMaintenanceMode
+func Test_App_SetMaintenanceMode(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.Get("/health", func(c *Ctx) error {
+		return c.SendString("healthy")
+	})
+	app.Get("/work", func(c *Ctx) error {
+		return c.SendString("working")
+	})
+
+	app.SetMaintenanceMode(true, func(c *Ctx) error {
+		return c.Status(StatusServiceUnavailable).SendString("down for maintenance")
+	}, "/health")
+
+	// allowlisted paths bypass maintenance mode
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/health", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	// everything else is answered by the maintenance handler
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/work", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusServiceUnavailable, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "down for maintenance", string(body))
+
+	// switching off restores normal routing
+	app.SetMaintenanceMode(false, nil)
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/work", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+}
+
+// go test -run Test_App_MaxPathSegments
+func Test_App_MaxPathSegments(t *testing.T) {
+	t.Parallel()
+	app := New(Config{MaxPathSegments: 3})
+
+	app.Get("/files/*", func(c *Ctx) error {
+		return c.SendString(c.Params("*"))
+	})
+
+	// just under the limit
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/files/deep/file.txt", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	// one segment over the limit
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/files/very/deep/file.txt", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusRequestURITooLong, resp.StatusCode)
+}
+
+// go test -run Test_App_MaxResponseBodySize
+func Test_App_MaxResponseBodySize(t *testing.T) {
+	t.Parallel()
+	app := New(Config{MaxResponseBodySize: 10})
+
+	app.Get("/small", func(c *Ctx) error {
+		return c.SendString("tiny")
+	})
+	app.Get("/big", func(c *Ctx) error {
+		return c.SendString(strings.Repeat("x", 64))
+	})
+
+	// responses under the cap pass untouched
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/small", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "tiny", string(body))
+
+	// oversized responses are dropped and replaced with a 500
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/big", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusInternalServerError, resp.StatusCode)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, utils.StatusMessage(StatusInternalServerError), string(body))
+}
+
+// go test -run Test_Ctx_ResponseBodySize
+func Test_Ctx_ResponseBodySize(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	var size int
+	app.Get("/", func(c *Ctx) error {
+		if err := c.SendString("hello"); err != nil {
+			return err
+		}
+		size = c.ResponseBodySize()
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, 5, size)
+}
+
+// go test -run Test_App_OnError
+func Test_App_OnError(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	var reported []error
+	app.OnError(func(_ *Ctx, err error) {
+		reported = append(reported, err)
+	})
+
+	app.Get("/ok", testEmptyHandler)
+	app.Get("/boom", func(_ *Ctx) error {
+		return errors.New("boom")
+	})
+
+	// successful requests do not fire
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/ok", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, 0, len(reported))
+
+	// the raw handler error is reported before the response is written
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/boom", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusInternalServerError, resp.StatusCode)
+	utils.AssertEqual(t, 1, len(reported))
+	utils.AssertEqual(t, "boom", reported[0].Error())
+}
+
+// go test -run Test_App_OnError_ServerError
+func Test_App_OnError_ServerError(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	var reported []error
+	app.OnError(func(_ *Ctx, err error) {
+		reported = append(reported, err)
+	})
+
+	c := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(c)
+	app.serverErrorHandler(c.fasthttp, errors.New("header too large"))
+
+	utils.AssertEqual(t, 1, len(reported))
+	utils.AssertEqual(t, "header too large", reported[0].Error())
+}
+
+// go test -run Test_App_EnableAutoOptions
+func Test_App_EnableAutoOptions(t *testing.T) {
+	t.Parallel()
+	app := New(Config{EnableAutoOptions: true})
+
+	app.Get("/users/:id", testEmptyHandler)
+	app.Put("/files/*", testEmptyHandler)
+
+	// wildcard route contributes its method for any matching subpath
+	resp, err := app.Test(httptest.NewRequest(MethodOptions, "/files/deep/nested/file.txt", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNoContent, resp.StatusCode)
+	utils.AssertEqual(t, MethodPut, resp.Header.Get(HeaderAllow))
+
+	// param route as well
+	resp, err = app.Test(httptest.NewRequest(MethodOptions, "/users/42", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNoContent, resp.StatusCode)
+	utils.AssertEqual(t, MethodGet+", "+MethodHead, resp.Header.Get(HeaderAllow))
+
+	// a registered OPTIONS route still wins
+	app.Options("/files/*", func(c *Ctx) error {
+		return c.SendString("custom")
+	})
+	resp, err = app.Test(httptest.NewRequest(MethodOptions, "/files/a", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	// no route under any method still 404s
+	resp, err = app.Test(httptest.NewRequest(MethodOptions, "/missing", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+}
+
+// go test -run Test_App_EnableAutoHead
+func Test_App_EnableAutoHead(t *testing.T) {
+	t.Parallel()
+
+	// strict default: HEAD on a POST-only path is a 405
+	app := New()
+	app.Post("/upload", testEmptyHandler)
+	resp, err := app.Test(httptest.NewRequest(MethodHead, "/upload", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusMethodNotAllowed, resp.StatusCode)
+
+	app = New(Config{EnableAutoHead: true})
+	app.Post("/upload", testEmptyHandler)
+	app.Get("/page", func(c *Ctx) error {
+		return c.SendString("body")
+	})
+
+	// opt-in: the POST-only path confirms its existence with an empty 200
+	resp, err = app.Test(httptest.NewRequest(MethodHead, "/upload", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, 0, len(body))
+
+	// the HEAD route paired with GET still answers as before
+	resp, err = app.Test(httptest.NewRequest(MethodHead, "/page", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	// unknown paths still 404
+	resp, err = app.Test(httptest.NewRequest(MethodHead, "/missing", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+}
+
+// go test -run Test_App_IdleConnReaper
+func Test_App_IdleConnReaper(t *testing.T) {
+	reaped := make(chan struct{}, 1)
+	app := New(Config{
+		IdleTimeout: 100 * time.Millisecond,
+		OnIdleConnReaped: func(net.Conn) {
+			select {
+			case reaped <- struct{}{}:
+			default:
+			}
+		},
+		DisableStartupMessage: true,
+	})
+	app.Get("/", testEmptyHandler)
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() {
+		utils.AssertEqual(t, nil, app.Listener(ln))
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial(NetworkTCP4, ln.Addr().String())
+	utils.AssertEqual(t, nil, err)
+	defer conn.Close() //nolint:errcheck // It is fine to ignore the error here
+
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	utils.AssertEqual(t, nil, err)
+	buf := make([]byte, 1024)
+	_, err = conn.Read(buf)
+	utils.AssertEqual(t, nil, err)
+
+	// the connection now sits idle and must be reaped after IdleTimeout
+	select {
+	case <-reaped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle connection was not reaped")
+	}
+
+	metrics := app.ConnMetrics()
+	utils.AssertEqual(t, true, metrics.ReapedIdle >= 1, "reap counter should increase")
+	utils.AssertEqual(t, int64(0), metrics.Active)
+
+	utils.AssertEqual(t, nil, app.ShutdownWithTimeout(time.Second))
+}
+
+// go test -run Test_App_UseApp
+func Test_App_UseApp(t *testing.T) {
+	t.Parallel()
+
+	bundle := New()
+	bundle.Use(func(c *Ctx) error {
+		c.Set("X-Bundle", "1")
+		return c.Next()
+	})
+	bundle.Use("/nested", func(c *Ctx) error {
+		c.Set("X-Nested", "1")
+		return c.Next()
+	})
+	bundle.Get("/endpoint", testEmptyHandler)
+
+	app := New()
+	app.UseApp("/admin", bundle)
+	app.Get("/admin/panel", testEmptyHandler)
+	app.Get("/admin/nested/deep", testEmptyHandler)
+	app.Get("/public", testEmptyHandler)
+
+	// bundle middleware runs under the prefix
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/admin/panel", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, "1", resp.Header.Get("X-Bundle"))
+	utils.AssertEqual(t, "", resp.Header.Get("X-Nested"))
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/admin/nested/deep", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "1", resp.Header.Get("X-Bundle"))
+	utils.AssertEqual(t, "1", resp.Header.Get("X-Nested"))
+
+	// but not outside of it
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/public", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "", resp.Header.Get("X-Bundle"))
+
+	// the bundle's endpoints are not imported
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/admin/endpoint", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+}
+
+// go test -run Test_App_UpgradeHandler
+func Test_App_UpgradeHandler(t *testing.T) {
+	t.Parallel()
+	app := New(Config{
+		UpgradeHandler: func(c *Ctx) error {
+			return ErrUpgradeRequired
+		},
+	})
+
+	app.Get("/plain", testEmptyHandler)
+	app.Get("/ws", testEmptyHandler).AllowUpgrade()
+
+	upgradeReq := func(target string) *http.Request {
+		req := httptest.NewRequest(MethodGet, target, nil)
+		req.Header.Set(HeaderConnection, "Upgrade")
+		req.Header.Set(HeaderUpgrade, "websocket")
+		return req
+	}
+
+	// upgrade against a normal route is rejected through the hook
+	resp, err := app.Test(upgradeReq("/plain"))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusUpgradeRequired, resp.StatusCode)
+
+	// an upgrade-capable route still runs its handlers
+	resp, err = app.Test(upgradeReq("/ws"))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	// plain requests are unaffected
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/plain", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+}
+
+// go test -run Test_App_UpgradeHandler_Unset
+func Test_App_UpgradeHandler_Unset(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.Get("/plain", testEmptyHandler)
+
+	req := httptest.NewRequest(MethodGet, "/plain", nil)
+	req.Header.Set(HeaderConnection, "Upgrade")
+	req.Header.Set(HeaderUpgrade, "websocket")
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+}
@@ -20,6 +20,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"text/template"
 	"time"
 
@@ -84,6 +85,7 @@ type Ctx struct {
 	route               *Route               // Reference to *Route
 	indexRoute          int                  // Index of the current route
 	indexHandler        int                  // Index of the current handler
+	compiledNext        Handler              // Continuation inside a compiled handler chain, see Config.EnableCompiledHandlerChains
 	method              string               // HTTP method
 	methodINT           int                  // HTTP method INT equivalent
 	baseURI             string               // HTTP base uri
@@ -96,6 +98,13 @@ type Ctx struct {
 	values              [maxParams]string    // Route parameter values
 	fasthttp            *fasthttp.RequestCtx // Reference to *fasthttp.RequestCtx
 	matched             bool                 // Non use route matched
+	skipMiddleware      bool                 // Terminal route opted out of middleware, see SkipMiddleware
+	consumesMismatch    bool                 // A route was skipped only for its accepted content types
+	schemeRedirect      bool                 // A https-only route was skipped for a plain request
+	attrs               map[string]string    // Request attributes set by pre-route hooks, see SetAttr
+	matchTrace          *MatchTrace          // Route match trace, nil unless Config.EnableMatchTrace
+	acquiredBuffers     []*bytes.Buffer      // Buffers handed out via AcquireBuffer, reclaimed on release
+	handlerTimings      []HandlerTiming      // Per-handler timings, only filled with Config.EnableHandlerTimings
 	viewBindMap         sync.Map             // Default view map to bind template engine
 }
 
@@ -170,13 +179,38 @@ func (app *App) AcquireCtx(fctx *fasthttp.RequestCtx) *Ctx {
 	// Reset route and handler index
 	c.indexRoute = -1
 	c.indexHandler = 0
+	// Reset compiled chain continuation
+	c.compiledNext = nil
 	// Reset matched flag
 	c.matched = false
+	// Reset middleware opt-out, resolved per routing pass
+	c.skipMiddleware = false
+	// Reset content-type dispatch miss
+	c.consumesMismatch = false
+	// Reset deferred https redirect
+	c.schemeRedirect = false
+	// Reset request attributes
+	c.attrs = nil
+	// Reset match trace
+	c.matchTrace = nil
+	// Reset handler timings, the backing array is reused
+	c.handlerTimings = c.handlerTimings[:0]
 	// Set paths
 	c.pathOriginal = app.getString(fctx.URI().PathOriginal())
 	// Set method
 	c.method = app.getString(fctx.Request.Header.Method())
 	c.methodINT = app.methodInt(c.method)
+	// Methods sent in the wrong case like "get" are aliased to their
+	// registered form; methods still unknown after uppercasing stay invalid
+	// and are rejected with 400 by app.handler
+	if c.methodINT == -1 {
+		if upper := utils.ToUpper(c.method); upper != c.method {
+			if mINT := app.methodInt(upper); mINT != -1 {
+				c.method = upper
+				c.methodINT = mINT
+			}
+		}
+	}
 	// Attach *fasthttp.RequestCtx to ctx
 	c.fasthttp = fctx
 	// reset base uri
@@ -192,9 +226,42 @@ func (app *App) ReleaseCtx(c *Ctx) {
 	c.route = nil
 	c.fasthttp = nil
 	c.viewBindMap = sync.Map{}
+	// Reclaim scratch buffers the handler forgot to release
+	for i, buf := range c.acquiredBuffers {
+		app.bufferPool.Put(buf)
+		c.acquiredBuffers[i] = nil
+	}
+	c.acquiredBuffers = c.acquiredBuffers[:0]
 	app.pool.Put(c)
 }
 
+// AcquireBuffer returns an empty scratch byte buffer from the application's
+// buffer pool, useful for handlers that build responses from temporary
+// buffers without allocating on every request. Return it with ReleaseBuffer
+// when done; buffers still held when the Ctx is released are reclaimed
+// automatically, so a forgotten release never leaks.
+func (c *Ctx) AcquireBuffer() *bytes.Buffer {
+	buf, ok := c.app.bufferPool.Get().(*bytes.Buffer)
+	if !ok {
+		buf = new(bytes.Buffer)
+	}
+	buf.Reset()
+	c.acquiredBuffers = append(c.acquiredBuffers, buf)
+	return buf
+}
+
+// ReleaseBuffer returns a buffer obtained via AcquireBuffer to the pool. The
+// buffer must not be used afterwards.
+func (c *Ctx) ReleaseBuffer(buf *bytes.Buffer) {
+	for i, b := range c.acquiredBuffers {
+		if b == buf {
+			c.acquiredBuffers = append(c.acquiredBuffers[:i], c.acquiredBuffers[i+1:]...)
+			break
+		}
+	}
+	c.app.bufferPool.Put(buf)
+}
+
 // Accepts checks if the specified extensions or content types are acceptable.
 func (c *Ctx) Accepts(offers ...string) string {
 	return getOffer(c.Get(HeaderAccept), acceptsOfferType, offers...)
@@ -357,6 +424,21 @@ func (c *Ctx) Body() []byte {
 	return body
 }
 
+// BodyStream returns a reader over the request body. With
+// Config.StreamRequestBody enabled the body is read from the connection as
+// the handler consumes it; otherwise the reader covers the buffered body.
+// Read errors caused by the client hanging up mid-upload wrap
+// ErrClientDisconnected, so a handler can return them as-is and app.handler
+// recognizes the disconnect, see Config.SkipErrorHandlerOnDisconnect.
+// Prefer this over reading Request().BodyStream() directly, raw stream errors
+// are not classified as disconnects.
+func (c *Ctx) BodyStream() io.Reader {
+	if stream := c.fasthttp.Request.BodyStream(); stream != nil {
+		return &disconnectAwareBody{reader: stream}
+	}
+	return bytes.NewReader(c.fasthttp.Request.Body())
+}
+
 func decoderBuilder(parserConfig ParserConfig) interface{} {
 	decoder := schema.NewDecoder()
 	decoder.IgnoreUnknownKeys(parserConfig.IgnoreUnknownKeys)
@@ -456,6 +538,26 @@ func (c *Ctx) Context() *fasthttp.RequestCtx {
 	return c.fasthttp
 }
 
+// RequestCtx returns the underlying *fasthttp.RequestCtx, an escape hatch
+// for the rare operations Fiber does not wrap. The returned ctx is only
+// valid until the handler returns: both Ctx and the fasthttp ctx are pooled
+// and reused for unrelated requests afterwards, so it must never be
+// retained across requests or handed to goroutines that may outlive the
+// handler. It is an alias of Context under the name the fasthttp origin
+// makes obvious.
+func (c *Ctx) RequestCtx() *fasthttp.RequestCtx {
+	return c.fasthttp
+}
+
+// responseCommitted reports whether parts of the response (status code or
+// body) were already written, in which case forcing another status code
+// would produce a corrupt response
+func (c *Ctx) responseCommitted() bool {
+	return c.fasthttp.Response.StatusCode() != StatusOK ||
+		len(c.fasthttp.Response.Body()) > 0 ||
+		c.fasthttp.Response.IsBodyStream()
+}
+
 // UserContext returns a context implementation that was set by
 // user earlier or returns a non-nil, empty context,if it was not set earlier.
 func (c *Ctx) UserContext() context.Context {
@@ -1018,13 +1120,18 @@ func (c *Ctx) ClientHelloInfo() *tls.ClientHelloInfo {
 
 // Next executes the next method in the stack that matches the current route.
 func (c *Ctx) Next() error {
+	// Compiled chains thread the continuation through a function pointer
+	// instead of the handler index, see Config.EnableCompiledHandlerChains
+	if c.compiledNext != nil {
+		return c.compiledNext(c)
+	}
 	// Increment handler index
 	c.indexHandler++
 	var err error
 	// Did we execute all route handlers?
 	if c.indexHandler < len(c.route.Handlers) {
 		// Continue route stack
-		err = c.route.Handlers[c.indexHandler](c)
+		err = c.runHandler(c.route.Handlers[c.indexHandler])
 	} else {
 		// Continue handler stack
 		_, err = c.app.next(c)
@@ -1032,6 +1139,42 @@ func (c *Ctx) Next() error {
 	return err
 }
 
+// HandlerTiming is one entry of the per-handler latency breakdown recorded
+// when Config.EnableHandlerTimings is set. Entries appear in invocation
+// order. Since handlers run nested through Next, each duration includes the
+// time spent in all downstream handlers; the exclusive cost of a handler is
+// its duration minus the following entry's.
+type HandlerTiming struct {
+	// Position of the handler in the invocation order, starting at 0
+	Index int `json:"index"`
+	// Registered path of the route the handler belongs to
+	Route string `json:"route"`
+	// Time spent in the handler including everything it called via Next
+	Duration time.Duration `json:"duration"`
+}
+
+// HandlerTimings returns the latency breakdown of the handlers that ran so
+// far for this request. It is empty unless Config.EnableHandlerTimings is
+// set. The returned slice is only valid until the handler returns.
+func (c *Ctx) HandlerTimings() []HandlerTiming {
+	return c.handlerTimings
+}
+
+// runHandler invokes a handler, recording its timing when enabled. The
+// timing entry is appended before the handler runs so nested invocations
+// via Next keep the invocation order.
+func (c *Ctx) runHandler(h Handler) error {
+	if !c.app.config.EnableHandlerTimings {
+		return h(c)
+	}
+	index := len(c.handlerTimings)
+	c.handlerTimings = append(c.handlerTimings, HandlerTiming{Index: index, Route: c.route.Path})
+	start := time.Now()
+	err := h(c)
+	c.handlerTimings[index].Duration = time.Since(start)
+	return err
+}
+
 // RestartRouting instead of going to the next handler. This may be useful after
 // changing the request path. Note that handlers might be executed again.
 func (c *Ctx) RestartRouting() error {
@@ -1050,6 +1193,9 @@ func (c *Ctx) OriginalURL() string {
 // Params is used to get the route parameters.
 // Defaults to empty string "" if the param doesn't exist.
 // If a default value is given, it will return that value if the param doesn't exist.
+// Percent-encoded characters in the value are decoded ("%20" -> " "), invalid
+// escape sequences are kept verbatim. Set DisableParamsDecoding to get the raw
+// value instead.
 // Returned value is only valid within the handler. Do not store any references.
 // Make copies or use the Immutable setting to use the value outside the Handler.
 func (c *Ctx) Params(key string, defaultValue ...string) string {
@@ -1065,12 +1211,27 @@ func (c *Ctx) Params(key string, defaultValue ...string) string {
 			if len(c.values) <= i || len(c.values[i]) == 0 {
 				break
 			}
-			return c.values[i]
+			if c.app.config.DisableParamsDecoding {
+				return c.values[i]
+			}
+			return decodePathParam(c.values[i])
 		}
 	}
 	return defaultString("", defaultValue)
 }
 
+// ParamsSlice returns the path segments captured by a named one-or-more
+// parameter like "/tree/+segments" as a slice, one entry per segment. Unlike
+// the greedy "*", a named "+" requires at least one segment to match. It
+// returns nil when the param doesn't exist or captured nothing.
+func (c *Ctx) ParamsSlice(key string) []string {
+	value := utils.TrimRight(c.Params(key), '/')
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, "/")
+}
+
 // AllParams Params is used to get all route parameters.
 // Using Params method to get params.
 func (c *Ctx) AllParams() map[string]string {
@@ -1109,6 +1270,14 @@ func (c *Ctx) ParamsInt(key string, defaultValue ...int) (int, error) {
 	return value, nil
 }
 
+// MatchTrace returns the route match trace recorded for this request: the
+// selected tree bucket and every route that was tested, in order, with the
+// reason it was rejected. It returns nil unless Config.EnableMatchTrace is on,
+// and is typically inspected in the error handler to debug unexpected 404s.
+func (c *Ctx) MatchTrace() *MatchTrace {
+	return c.matchTrace
+}
+
 // Path returns the path part of the request URL.
 // Optionally, you could override the path.
 func (c *Ctx) Path(override ...string) string {
@@ -1124,6 +1293,17 @@ func (c *Ctx) Path(override ...string) string {
 	return c.path
 }
 
+// TreePath returns the tree bucket key the router derived for this request:
+// the first three characters of the detection path, or "" for shorter
+// paths. The router matches the
+// request only against the routes bucketed under this key; when no bucket
+// exists for it, the whole catch-all "" bucket is scanned instead, which
+// MatchTrace reports as Bucket. Read-only and cheap, useful for diagnosing
+// slow routes.
+func (c *Ctx) TreePath() string {
+	return c.treePath
+}
+
 // Protocol contains the request protocol string: http or https for TLS requests.
 // Please use Config.EnableTrustedProxyCheck to prevent header spoofing, in case when your app is behind the proxy.
 func (c *Ctx) Protocol() string {
@@ -1664,7 +1844,24 @@ func (c *Ctx) renderExtensions(bind interface{}) {
 	}
 }
 
-// Route returns the matched Route struct.
+// NormalizedHost returns the request's host canonicalized for host-based
+// matching: lowercased, without a trailing dot and, unless
+// Config.PreserveHostPort is set, without the port. "Example.COM.:8080"
+// becomes "example.com", "[::1]:8080" becomes "[::1]".
+func (c *Ctx) NormalizedHost() string {
+	return normalizeHost(c.Hostname(), c.app.config.PreserveHostPort)
+}
+
+// HandlerIndex returns the position of the currently running handler within
+// the matched route's handler chain, starting at 0. Together with
+// len(c.Route().Handlers) middleware can reason about its place in the
+// chain, e.g. whether it is the terminal handler.
+func (c *Ctx) HandlerIndex() int {
+	return c.indexHandler
+}
+
+// Route returns the matched Route struct. The route is shared routing state,
+// it must not be mutated; use the registration APIs to change routes.
 func (c *Ctx) Route() *Route {
 	if c.route == nil {
 		// Fallback for fasthttp error handler
@@ -1679,6 +1876,58 @@ func (c *Ctx) Route() *Route {
 	return c.route
 }
 
+// queryConstraintsMatch reports whether the request's query string satisfies
+// every required query param value of the route.
+func (c *Ctx) queryConstraintsMatch(route *Route) bool {
+	for key, value := range route.queryConstraints {
+		if c.Query(key) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// SetAttr attaches an attribute to the request, typically from a hook
+// registered via App.OnPreRoute before matching starts. Routes constrain on
+// attributes via Attr. The attribute only lives for the current request.
+func (c *Ctx) SetAttr(key, value string) {
+	if c.attrs == nil {
+		c.attrs = make(map[string]string)
+	}
+	c.attrs[key] = value
+}
+
+// Attr returns the request attribute stored under the given key, or an empty
+// string when it was never set.
+func (c *Ctx) Attr(key string) string {
+	return c.attrs[key]
+}
+
+// attrConstraintsMatch reports whether the request's attributes satisfy every
+// required attribute value of the route.
+func (c *Ctx) attrConstraintsMatch(route *Route) bool {
+	for key, value := range route.attrConstraints {
+		if c.attrs[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// MetricPath returns the label to aggregate metrics by for this request.
+// By default this is the matched route's registered path template, so all
+// requests to "/users/:id" share one label. When a transformer was installed
+// via App.SetMetricPathTransformer, its output is returned instead. The
+// transformer runs on demand, requests that never call MetricPath pay
+// nothing.
+func (c *Ctx) MetricPath() string {
+	route := c.Route()
+	if transformer := c.app.metricPathTransformer; transformer != nil {
+		return transformer(route, c.Path())
+	}
+	return route.Path
+}
+
 // SaveFile saves any multipart file to disk.
 func (*Ctx) SaveFile(fileheader *multipart.FileHeader, path string) error {
 	return fasthttp.SaveMultipartFile(fileheader, path)
@@ -1816,8 +2065,73 @@ func (c *Ctx) SendString(body string) error {
 	return nil
 }
 
-// SendStream sets response body stream and optional body size.
+// errResponseTooLarge aborts a body stream that crossed MaxResponseBodySize,
+// the broken stream makes fasthttp drop the connection
+var errResponseTooLarge = errors.New("body stream: response body exceeds MaxResponseBodySize")
+
+// maxBytesReader wraps a response body stream and fails it once more than
+// the configured number of bytes were read from it.
+type maxBytesReader struct {
+	io.Reader
+	remaining int
+}
+
+func (r *maxBytesReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.remaining -= n
+	if r.remaining < 0 {
+		return n, errResponseTooLarge
+	}
+	return n, err //nolint:wrapcheck // forward the stream's error untouched
+}
+
+// streamSlotReleaser wraps a response body stream and gives the occupied
+// concurrency slot back once the stream is exhausted or closed, whichever
+// happens first.
+type streamSlotReleaser struct {
+	io.Reader
+	app  *App
+	once sync.Once
+}
+
+func (r *streamSlotReleaser) release() {
+	atomic.AddInt64(&r.app.activeStreams, -1)
+}
+
+func (r *streamSlotReleaser) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil {
+		r.once.Do(r.release)
+	}
+	return n, err //nolint:wrapcheck // forward the stream's error untouched
+}
+
+func (r *streamSlotReleaser) Close() error {
+	r.once.Do(r.release)
+	if closer, ok := r.Reader.(io.Closer); ok {
+		return closer.Close() //nolint:wrapcheck // forward the stream's error untouched
+	}
+	return nil
+}
+
+// SendStream sets response body stream and optional body size. When
+// Config.MaxConcurrentStreams is set, a stream beyond the limit is rejected
+// with 503 Service Unavailable and a Retry-After header; the slot is freed
+// when the stream has been fully sent or the connection drops.
 func (c *Ctx) SendStream(stream io.Reader, size ...int) error {
+	// the cap has to sit innermost so a failing stream still releases its
+	// concurrency slot through the outer wrapper
+	if limit := c.app.config.MaxResponseBodySize; limit > 0 {
+		stream = &maxBytesReader{Reader: stream, remaining: limit}
+	}
+	if limit := c.app.config.MaxConcurrentStreams; limit > 0 {
+		if atomic.AddInt64(&c.app.activeStreams, 1) > int64(limit) {
+			atomic.AddInt64(&c.app.activeStreams, -1)
+			c.Set(HeaderRetryAfter, "1")
+			return ErrServiceUnavailable
+		}
+		stream = &streamSlotReleaser{Reader: stream, app: c.app}
+	}
 	if len(size) > 0 && size[0] >= 0 {
 		c.fasthttp.Response.SetBodyStream(stream, size[0])
 	} else {
@@ -1827,6 +2141,15 @@ func (c *Ctx) SendStream(stream io.Reader, size ...int) error {
 	return nil
 }
 
+// ResponseBodySize returns the number of body bytes buffered in the response
+// so far. Streaming responses report 0, their size is unknown until sent.
+func (c *Ctx) ResponseBodySize() int {
+	if c.fasthttp.Response.IsBodyStream() {
+		return 0
+	}
+	return len(c.fasthttp.Response.Body())
+}
+
 // Set sets the response's HTTP header field to the specified key, value.
 func (c *Ctx) Set(key, val string) {
 	c.fasthttp.Response.Header.Set(key, val)
@@ -1923,21 +2246,49 @@ func (c *Ctx) XHR() bool {
 // here the features for caseSensitive, decoded paths, strict paths are evaluated
 func (c *Ctx) configDependentPaths() {
 	c.pathBuffer = append(c.pathBuffer[0:0], c.pathOriginal...)
+	c.derivePaths()
+}
+
+// rewritePath points route matching at target while Ctx.OriginalURL keeps
+// reporting the URL the client requested. Used for the app's rewrite rules.
+func (c *Ctx) rewritePath(target string) {
+	c.pathBuffer = append(c.pathBuffer[0:0], target...)
+	c.derivePaths()
+}
+
+// derivePaths computes the user-facing path, the detection path and the tree
+// path from the current path buffer
+func (c *Ctx) derivePaths() {
 	// If UnescapePath enabled, we decode the path and save it for the framework user
 	if c.app.config.UnescapePath {
 		c.pathBuffer = fasthttp.AppendUnquotedArg(c.pathBuffer[:0], c.pathBuffer)
 	}
+	// If CollapseSlashes enabled, we collapse consecutive slashes into one
+	if c.app.config.CollapseSlashes {
+		c.pathBuffer = collapseSlashes(c.pathBuffer)
+	}
 	c.path = c.app.getString(c.pathBuffer)
 
 	// another path is specified which is for routing recognition only
 	// use the path that was changed by the previous configuration flags
 	c.detectionPathBuffer = append(c.detectionPathBuffer[0:0], c.pathBuffer...)
+	// If UnescapeDetectionPath enabled, decode unreserved percent-escapes for
+	// matching only, the user-facing path keeps them. With UnescapePath the
+	// buffer is fully decoded already
+	if c.app.config.UnescapeDetectionPath && !c.app.config.UnescapePath {
+		c.detectionPathBuffer = decodeUnreservedEscapes(c.detectionPathBuffer)
+	}
 	// If CaseSensitive is disabled, we lowercase the original path
 	if !c.app.config.CaseSensitive {
 		c.detectionPathBuffer = utils.ToLowerBytes(c.detectionPathBuffer)
 	}
-	// If StrictRouting is disabled, we strip all trailing slashes
-	if !c.app.config.StrictRouting && len(c.detectionPathBuffer) > 1 && c.detectionPathBuffer[len(c.detectionPathBuffer)-1] == '/' {
+	// If StrictRouting is disabled, we strip all trailing slashes,
+	// unless strict matching is requested for the current (mutating) method
+	strict := c.app.config.StrictRouting
+	if c.app.config.StrictForMutatingMethods && !isSafeMethod(c.method) {
+		strict = true
+	}
+	if !strict && len(c.detectionPathBuffer) > 1 && c.detectionPathBuffer[len(c.detectionPathBuffer)-1] == '/' {
 		c.detectionPathBuffer = utils.TrimRightBytes(c.detectionPathBuffer, '/')
 	}
 	c.detectionPath = c.app.getString(c.detectionPathBuffer)
@@ -634,6 +634,38 @@ func Test_Ctx_ParamParser(t *testing.T) {
 	utils.AssertEqual(t, nil, err)
 }
 
+// go test -run Test_Ctx_ParamsParser_Types
+func Test_Ctx_ParamsParser_Types(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/users/:id/:name/:active", func(c *Ctx) error {
+		d := new(struct {
+			ID     int    `params:"id"`
+			Name   string `params:"name"`
+			Active bool   `params:"active"`
+		})
+		utils.AssertEqual(t, nil, c.ParamsParser(d))
+		utils.AssertEqual(t, 42, d.ID)
+		utils.AssertEqual(t, "john", d.Name)
+		utils.AssertEqual(t, true, d.Active)
+		return nil
+	})
+	app.Get("/broken/:id", func(c *Ctx) error {
+		d := new(struct {
+			ID int `params:"id"`
+		})
+		err := c.ParamsParser(d)
+		utils.AssertEqual(t, false, err == nil, "unconvertible value must error")
+		return nil
+	})
+
+	_, err := app.Test(httptest.NewRequest(MethodGet, "/users/42/john/true", nil))
+	utils.AssertEqual(t, nil, err)
+
+	_, err = app.Test(httptest.NewRequest(MethodGet, "/broken/notanumber", nil))
+	utils.AssertEqual(t, nil, err)
+}
+
 // go test -run Test_Ctx_BodyParser_WithSetParserDecoder
 func Test_Ctx_BodyParser_WithSetParserDecoder(t *testing.T) {
 	type CustomTime time.Time
@@ -817,6 +849,23 @@ func Test_Ctx_Context(t *testing.T) {
 	utils.AssertEqual(t, "*fasthttp.RequestCtx", fmt.Sprintf("%T", c.Context()))
 }
 
+// go test -run Test_Ctx_RequestCtx
+func Test_Ctx_RequestCtx(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/", func(c *Ctx) error {
+		utils.AssertEqual(t, c.Context(), c.RequestCtx())
+		// use the raw fasthttp ctx directly for something Fiber wraps
+		c.RequestCtx().Response.Header.Set("X-Raw", "yes")
+		return c.SendString("ok")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, "yes", resp.Header.Get("X-Raw"))
+}
+
 // go test -run Test_Ctx_UserContext
 func Test_Ctx_UserContext(t *testing.T) {
 	app := New()
@@ -2304,6 +2353,31 @@ func Benchmark_Ctx_ParamsParse(b *testing.B) {
 	utils.AssertEqual(b, "awesome", res.Param4)
 }
 
+// go test -run Test_Ctx_TreePath
+func Test_Ctx_TreePath(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/api/users", func(c *Ctx) error {
+		return c.SendString(c.TreePath())
+	})
+	app.Get("/", func(c *Ctx) error {
+		return c.SendString("[" + c.TreePath() + "]")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/api/users", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "/ap", string(body))
+
+	// paths shorter than three characters land in the catch-all bucket
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "[]", string(body))
+}
+
 // go test -run Test_Ctx_Path
 func Test_Ctx_Path(t *testing.T) {
 	t.Parallel()
@@ -5673,3 +5747,320 @@ func Test_Ctx_extractIPsFromHeader_EnableValidateIp(t *testing.T) {
 	res := ips[len(ips)-2]
 	utils.AssertEqual(t, "42.118.81.169", res)
 }
+
+// go test -run Test_Ctx_Params_Decoding
+func Test_Ctx_Params_Decoding(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/user/:name", func(c *Ctx) error {
+		utils.AssertEqual(t, "John Doe", c.Params("name"))
+		return nil
+	})
+	app.Get("/slash/:name", func(c *Ctx) error {
+		// an encoded slash is decoded but did not split the segment
+		utils.AssertEqual(t, "a/b", c.Params("name"))
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/user/John%20Doe", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/slash/a%2Fb", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_Ctx_Params_DisableDecoding
+func Test_Ctx_Params_DisableDecoding(t *testing.T) {
+	t.Parallel()
+	app := New(Config{DisableParamsDecoding: true})
+	app.Get("/user/:name", func(c *Ctx) error {
+		utils.AssertEqual(t, "John%20Doe", c.Params("name"))
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/user/John%20Doe", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_Ctx_Params_CasePreserved
+func Test_Ctx_Params_CasePreserved(t *testing.T) {
+	t.Parallel()
+	// matching is case-insensitive by default, but captured values must keep
+	// the original casing of the request path
+	app := New()
+	app.Get("/user/:name", func(c *Ctx) error {
+		utils.AssertEqual(t, "JohnDoe", c.Params("name"))
+		return nil
+	})
+	app.Get("/Mixed/:name/*", func(c *Ctx) error {
+		utils.AssertEqual(t, "JohnDoe", c.Params("name"))
+		utils.AssertEqual(t, "Sub/Path", c.Params("*"))
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/USER/JohnDoe", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/mixed/JohnDoe/Sub/Path", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_Ctx_AcquireBuffer
+func Test_Ctx_AcquireBuffer(t *testing.T) {
+	t.Parallel()
+	app := New()
+	c := app.AcquireCtx(&fasthttp.RequestCtx{})
+
+	buf := c.AcquireBuffer()
+	buf.WriteString("hello")
+	utils.AssertEqual(t, "hello", buf.String())
+	utils.AssertEqual(t, 1, len(c.acquiredBuffers))
+
+	// an explicit release drops the tracking entry
+	c.ReleaseBuffer(buf)
+	utils.AssertEqual(t, 0, len(c.acquiredBuffers))
+
+	// a forgotten release is reclaimed when the Ctx goes back into the pool
+	leaked := c.AcquireBuffer()
+	leaked.WriteString("leak")
+	app.ReleaseCtx(c)
+
+	c = app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(c)
+	reused := c.AcquireBuffer()
+	utils.AssertEqual(t, true, leaked == reused)
+	// reacquired buffers always come back empty
+	utils.AssertEqual(t, 0, reused.Len())
+	c.ReleaseBuffer(reused)
+}
+
+// go test -v -run=^$ -bench=Benchmark_Ctx_AcquireBuffer -benchmem -count=4
+func Benchmark_Ctx_AcquireBuffer(b *testing.B) {
+	app := New()
+	c := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(c)
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			buf := c.AcquireBuffer()
+			buf.WriteString(`{"name":"john","age":32}`)
+			c.ReleaseBuffer(buf)
+		}
+	})
+	b.Run("allocated", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			buf := new(bytes.Buffer)
+			buf.WriteString(`{"name":"john","age":32}`)
+		}
+	})
+}
+
+// go test -run Test_Ctx_MetricPath
+func Test_Ctx_MetricPath(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.Get("/users/:id", func(c *Ctx) error {
+		return c.SendString(c.MetricPath())
+	})
+	// legacy route with an undeclared ID-like last segment
+	app.Get("/legacy/report/*", func(c *Ctx) error {
+		return c.SendString(c.MetricPath())
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/users/42", nil))
+	utils.AssertEqual(t, nil, err)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "/users/:id", string(body))
+
+	app.SetMetricPathTransformer(func(route *Route, path string) string {
+		if strings.HasPrefix(path, "/legacy/report/") {
+			return "/legacy/report/:id"
+		}
+		return route.Path
+	})
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/legacy/report/1337", nil))
+	utils.AssertEqual(t, nil, err)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "/legacy/report/:id", string(body))
+}
+
+// go test -run Test_Ctx_HandlerTimings
+func Test_Ctx_HandlerTimings(t *testing.T) {
+	t.Parallel()
+	app := New(Config{EnableHandlerTimings: true})
+
+	var timings []HandlerTiming
+	app.Use(func(c *Ctx) error {
+		err := c.Next()
+		// all downstream handlers returned, copy the breakdown
+		timings = append(timings[:0], c.HandlerTimings()...)
+		return err
+	})
+	app.Use(func(c *Ctx) error {
+		return c.Next()
+	})
+	app.Get("/timed", func(c *Ctx) error {
+		time.Sleep(5 * time.Millisecond)
+		return c.SendString("done")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/timed", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	utils.AssertEqual(t, 3, len(timings))
+	for i, timing := range timings {
+		utils.AssertEqual(t, i, timing.Index)
+	}
+	utils.AssertEqual(t, "/timed", timings[2].Route)
+	// nested invocations include downstream time, so the order of
+	// magnitude must be preserved from the terminal handler outwards
+	utils.AssertEqual(t, true, timings[2].Duration >= 5*time.Millisecond)
+	utils.AssertEqual(t, true, timings[1].Duration >= timings[2].Duration)
+}
+
+// go test -run Test_Ctx_HandlerTimings_Disabled
+func Test_Ctx_HandlerTimings_Disabled(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.Get("/", func(c *Ctx) error {
+		utils.AssertEqual(t, 0, len(c.HandlerTimings()))
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+}
+
+// go test -run Test_Ctx_SendStream_ConcurrencyLimit
+func Test_Ctx_SendStream_ConcurrencyLimit(t *testing.T) {
+	app := New(Config{MaxConcurrentStreams: 1, DisableStartupMessage: true})
+
+	writers := make(chan *io.PipeWriter, 2)
+	app.Get("/stream", func(c *Ctx) error {
+		pr, pw := io.Pipe()
+		if err := c.SendStream(pr); err != nil {
+			_ = pw.Close() //nolint:errcheck // It is fine to ignore the error here
+			return err
+		}
+		// prime the stream so the response headers are flushed
+		go func() {
+			_, _ = pw.Write([]byte("hello")) //nolint:errcheck // It is fine to ignore the error here
+		}()
+		writers <- pw
+		return nil
+	})
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() {
+		utils.AssertEqual(t, nil, app.Listener(ln))
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	request := func() (net.Conn, string) {
+		conn, err := net.Dial(NetworkTCP4, ln.Addr().String())
+		utils.AssertEqual(t, nil, err)
+		_, err = conn.Write([]byte("GET /stream HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, nil, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		buf := make([]byte, 256)
+		n, err := conn.Read(buf)
+		utils.AssertEqual(t, nil, err)
+		return conn, string(buf[:n])
+	}
+
+	// first stream occupies the only slot
+	conn1, status1 := request()
+	defer conn1.Close() //nolint:errcheck // It is fine to ignore the error here
+	utils.AssertEqual(t, true, strings.Contains(status1, "HTTP/1.1 200"), status1)
+	pw := <-writers
+	utils.AssertEqual(t, int64(1), app.ActiveStreams())
+
+	// second stream is rejected while the slot is taken
+	conn2, status2 := request()
+	defer conn2.Close() //nolint:errcheck // It is fine to ignore the error here
+	utils.AssertEqual(t, true, strings.Contains(status2, "HTTP/1.1 503"), status2)
+	utils.AssertEqual(t, true, strings.Contains(status2, "Retry-After: 1"), status2)
+
+	// ending the first stream frees the slot
+	utils.AssertEqual(t, nil, pw.Close())
+	deadline := time.Now().Add(2 * time.Second)
+	for app.ActiveStreams() != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	utils.AssertEqual(t, int64(0), app.ActiveStreams())
+
+	conn3, status3 := request()
+	defer conn3.Close() //nolint:errcheck // It is fine to ignore the error here
+	utils.AssertEqual(t, true, strings.Contains(status3, "HTTP/1.1 200"), status3)
+	utils.AssertEqual(t, nil, (<-writers).Close())
+
+	utils.AssertEqual(t, nil, app.ShutdownWithTimeout(time.Second))
+}
+
+// go test -run Test_Ctx_HandlerIndex
+func Test_Ctx_HandlerIndex(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	var indexes []int
+	var total int
+	record := func(c *Ctx) error {
+		indexes = append(indexes, c.HandlerIndex())
+		return c.Next()
+	}
+	app.Get("/chain", record, record, func(c *Ctx) error {
+		indexes = append(indexes, c.HandlerIndex())
+		total = len(c.Route().Handlers)
+		return c.SendString("done")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/chain", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, []int{0, 1, 2}, indexes)
+	utils.AssertEqual(t, 3, total)
+}
+
+// go test -run Test_Ctx_NormalizedHost
+func Test_Ctx_NormalizedHost(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/", func(c *Ctx) error {
+		return c.SendString(c.NormalizedHost())
+	})
+
+	req := httptest.NewRequest(MethodGet, "/", nil)
+	req.Host = "Example.COM.:8080"
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "example.com", string(body))
+
+	portApp := New(Config{PreserveHostPort: true})
+	portApp.Get("/", func(c *Ctx) error {
+		return c.SendString(c.NormalizedHost())
+	})
+	req = httptest.NewRequest(MethodGet, "/", nil)
+	req.Host = "Example.com:8080"
+	resp, err = portApp.Test(req)
+	utils.AssertEqual(t, nil, err)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "example.com:8080", string(body))
+}
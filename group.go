@@ -45,6 +45,102 @@ func (grp *Group) Name(name string) Router {
 	return grp
 }
 
+// SkipMiddleware marks the latest registered route to run without any use
+// middleware.
+func (grp *Group) SkipMiddleware() Router {
+	grp.app.SkipMiddleware()
+
+	return grp
+}
+
+// CacheControl assigns a Cache-Control directive to the latest registered route.
+// The directive is set on the response after the handler ran, unless the
+// handler already set its own Cache-Control header or returned an error.
+func (grp *Group) CacheControl(value string) Router {
+	grp.app.CacheControl(value)
+
+	return grp
+}
+
+// Scheme restricts the latest registered route to requests of the given
+// scheme, "http" or "https".
+func (grp *Group) Scheme(scheme string) Router {
+	grp.app.Scheme(scheme)
+
+	return grp
+}
+
+// Consumes restricts the latest registered route to requests whose
+// Content-Type matches one of the given media types.
+func (grp *Group) Consumes(types ...string) Router {
+	grp.app.Consumes(types...)
+
+	return grp
+}
+
+// DisableCompression marks the latest registered route as serving content
+// that must not be (re-)compressed.
+func (grp *Group) DisableCompression() Router {
+	grp.app.DisableCompression()
+
+	return grp
+}
+
+// AllowUpgrade marks the latest registered route as handling protocol
+// upgrades itself.
+func (grp *Group) AllowUpgrade() Router {
+	grp.app.AllowUpgrade()
+
+	return grp
+}
+
+// Related declares a relation between the latest registered route and a named
+// route, emitted as a Link header after the handler ran.
+func (grp *Group) Related(rel, routeName string) Router {
+	grp.app.Related(rel, routeName)
+
+	return grp
+}
+
+// Validate prepends a validation middleware generated from the given struct
+// schema to the handler chain of the latest registered route.
+func (grp *Group) Validate(schema interface{}) Router {
+	grp.app.Validate(schema)
+
+	return grp
+}
+
+// Query constrains the latest registered route to requests whose query
+// string carries the given param with exactly the given value.
+func (grp *Group) Query(key, value string) Router {
+	grp.app.Query(key, value)
+
+	return grp
+}
+
+// Attr constrains the latest registered route to requests carrying the given
+// attribute value, resolved by OnPreRoute hooks before matching.
+func (grp *Group) Attr(key, value string) Router {
+	grp.app.Attr(key, value)
+
+	return grp
+}
+
+// LogAs ties the latest registered route to a named log format from
+// Config.LogFormats, rendered after each request to the route.
+func (grp *Group) LogAs(name string) Router {
+	grp.app.LogAs(name)
+
+	return grp
+}
+
+// When attaches a match predicate to the latest registered route.
+func (grp *Group) When(predicate func(*Ctx) bool) Router {
+	grp.app.When(predicate)
+
+	return grp
+}
+
 // Use registers a middleware route that will match requests
 // with the provided prefix (which is optional and defaults to "/").
 //
@@ -156,6 +252,28 @@ func (grp *Group) Add(method, path string, handlers ...Handler) Router {
 	return grp
 }
 
+// AddExact registers a route whose path is matched exactly as written,
+// bypassing the CaseSensitive and StrictRouting normalization.
+func (grp *Group) AddExact(method, path string, handlers ...Handler) Router {
+	grp.app.registerWithOptions(method, getGroupPath(grp.Prefix, path), grp, registerOptions{exactPath: true}, handlers...)
+	if !grp.anyRouteDefined {
+		grp.anyRouteDefined = true
+	}
+
+	return grp
+}
+
+// AddExactMethod registers a route whose method is matched in the exact case
+// given, bypassing the uppercase normalization register applies.
+func (grp *Group) AddExactMethod(method, path string, handlers ...Handler) Router {
+	grp.app.registerWithOptions(method, getGroupPath(grp.Prefix, path), grp, registerOptions{exactMethod: true}, handlers...)
+	if !grp.anyRouteDefined {
+		grp.anyRouteDefined = true
+	}
+
+	return grp
+}
+
 // Static will create a file server serving static files
 func (grp *Group) Static(prefix, root string, config ...Static) Router {
 	grp.app.registerStatic(getGroupPath(grp.Prefix, prefix), root, config...)
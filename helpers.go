@@ -7,6 +7,7 @@ package fiber
 import (
 	"bytes"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"hash/crc32"
 	"io"
@@ -14,7 +15,9 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -94,10 +97,36 @@ func (app *App) quoteString(raw string) string {
 	return quoted
 }
 
+// trailingSlashMatch reports whether the request would have matched a route
+// of its own method with the trailing slash toggled, the secondary lookup
+// behind Config.TrailingSlashHandler
+func (app *App) trailingSlashMatch(ctx *Ctx) bool {
+	alt := ctx.detectionPath
+	if len(alt) > 1 && alt[len(alt)-1] == '/' {
+		alt = utils.TrimRight(alt, '/')
+	} else {
+		alt += "/"
+	}
+	var values [maxParams]string
+	for _, route := range app.stack[ctx.methodINT] {
+		if route.use || route.mount {
+			continue
+		}
+		if atomic.LoadInt32(&route.disabled) != 0 {
+			continue
+		}
+		if route.match(alt, alt, &values) {
+			return true
+		}
+	}
+	return false
+}
+
 // Scan stack if other methods match the request
 func (app *App) methodExist(ctx *Ctx) bool {
 	var exists bool
 	methods := app.config.RequestMethods
+	treeStack := ctx.app.getTreeStack()
 	for i := 0; i < len(methods); i++ {
 		// Skip original method
 		if ctx.methodINT == i {
@@ -105,9 +134,9 @@ func (app *App) methodExist(ctx *Ctx) bool {
 		}
 		// Reset stack index
 		indexRoute := -1
-		tree, ok := ctx.app.treeStack[i][ctx.treePath]
+		tree, ok := treeStack[i][ctx.treePath]
 		if !ok {
-			tree = ctx.app.treeStack[i][""]
+			tree = treeStack[i][""]
 		}
 		// Get stack length
 		lenr := len(tree) - 1
@@ -121,6 +150,22 @@ func (app *App) methodExist(ctx *Ctx) bool {
 			if route.use {
 				continue
 			}
+			// Skip routes toggled off at runtime
+			if atomic.LoadInt32(&route.disabled) != 0 {
+				continue
+			}
+			// Skip routes restricted to another request scheme
+			if route.scheme != "" && route.scheme != ctx.Protocol() {
+				continue
+			}
+			// Skip routes whose predicate rejects the request
+			if route.predicate != nil && !route.predicate(ctx) {
+				continue
+			}
+			// Skip routes whose query constraints are not satisfied
+			if len(route.queryConstraints) > 0 && !ctx.queryConstraintsMatch(route) {
+				continue
+			}
 			// Check if it matches the request path
 			match := route.match(ctx.detectionPath, ctx.path, &ctx.values)
 			// No match, next route
@@ -137,6 +182,289 @@ func (app *App) methodExist(ctx *Ctx) bool {
 	return exists
 }
 
+// invalidPathCharPosition returns the position of the first disallowed
+// character in a route path, or -1 when the path is clean. Control
+// characters are always disallowed, spaces only in strict mode. Escape
+// sequences are skipped, keeping intentionally escaped characters allowed.
+func invalidPathCharPosition(path string, strict bool) int {
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case c == escapeChar:
+			// skip the escaped character
+			i++
+		case c < 0x20 || c == 0x7f:
+			return i
+		case strict && c == ' ':
+			return i
+		}
+	}
+	return -1
+}
+
+// collapseSlashes collapses consecutive slashes in a path into a single one,
+// in place, e.g. "/api//users" -> "/api/users"
+func collapseSlashes(path []byte) []byte {
+	var n int
+	var prev byte
+	for _, cur := range path {
+		if cur == '/' && prev == '/' {
+			continue
+		}
+		path[n] = cur
+		n++
+		prev = cur
+	}
+	return path[:n]
+}
+
+// hexDigitValue returns the numeric value of a hex digit and whether the
+// byte is a valid hex digit at all
+func hexDigitValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// decodePathParam percent-decodes a captured route parameter value,
+// e.g. "John%20Doe" -> "John Doe". Invalid or truncated escape sequences
+// like "%ZZ" are kept verbatim instead of failing the request, and a plus
+// sign carries no special meaning in a path, so it is kept as well.
+func decodePathParam(value string) string {
+	if strings.IndexByte(value, '%') == -1 {
+		return value
+	}
+	decoded := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		if value[i] == '%' && i+2 < len(value) {
+			hi, okHi := hexDigitValue(value[i+1])
+			lo, okLo := hexDigitValue(value[i+2])
+			if okHi && okLo {
+				decoded = append(decoded, hi<<4|lo)
+				i += 2
+				continue
+			}
+		}
+		decoded = append(decoded, value[i])
+	}
+	return string(decoded)
+}
+
+// consumesMatch reports whether the request content type satisfies one of the
+// accepted types. Accepted types may use a "type/*" wildcard, parameters like
+// "; charset=utf-8" on the request value are ignored.
+func consumesMatch(contentType string, accepted []string) bool {
+	contentType = utils.ToLower(utils.Trim(contentType, ' '))
+	if i := strings.IndexByte(contentType, ';'); i != -1 {
+		contentType = utils.TrimRight(contentType[:i], ' ')
+	}
+	for _, accept := range accepted {
+		if accept == contentType || accept == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(accept, "/*") && strings.HasPrefix(contentType, accept[:len(accept)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidationError describes a single schema field that failed validation,
+// see App.Validate.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// validationRule is one check derived from the schema struct tags.
+type validationRule struct {
+	field    string // JSON name of the field, used in error responses
+	index    int    // Field index within the schema struct
+	required bool
+}
+
+// buildValidationHandler reflects over the given struct schema once and
+// returns a middleware that parses the request body into a fresh instance of
+// the schema type and enforces the derived rules. It panics when the schema
+// is not a struct or a pointer to one, mirroring other registration errors.
+func buildValidationHandler(schema interface{}) Handler {
+	schemaType := reflect.TypeOf(schema)
+	for schemaType != nil && schemaType.Kind() == reflect.Ptr {
+		schemaType = schemaType.Elem()
+	}
+	if schemaType == nil || schemaType.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: schema must be a struct or pointer to struct, got %v\n", reflect.TypeOf(schema)))
+	}
+
+	var rules []validationRule
+	for i := 0; i < schemaType.NumField(); i++ {
+		field := schemaType.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		name := field.Tag.Get("json")
+		if j := strings.IndexByte(name, ','); j != -1 {
+			name = name[:j]
+		}
+		if name == "" {
+			name = utils.ToLower(field.Name)
+		}
+		rules = append(rules, validationRule{
+			field:    name,
+			index:    i,
+			required: field.Tag.Get("validate") == "required",
+		})
+	}
+
+	return func(c *Ctx) error {
+		value := reflect.New(schemaType)
+		if err := c.BodyParser(value.Interface()); err != nil {
+			return c.Status(StatusBadRequest).JSON(Map{
+				"errors": []ValidationError{{Field: "", Message: "invalid request body"}},
+			})
+		}
+
+		var errs []ValidationError
+		for _, rule := range rules {
+			if rule.required && value.Elem().Field(rule.index).IsZero() {
+				errs = append(errs, ValidationError{Field: rule.field, Message: "is required"})
+			}
+		}
+		if len(errs) > 0 {
+			return c.Status(StatusBadRequest).JSON(Map{"errors": errs})
+		}
+
+		return c.Next()
+	}
+}
+
+// normalizeHost canonicalizes a Host header value for host matching:
+// lowercase, without the trailing dot of a fully-qualified domain name and,
+// unless preservePort is set, without the port. Bracketed IPv6 literals like
+// "[::1]:8080" keep their brackets and lose only the port; bare IPv6
+// literals are returned unchanged since their colons are not a port
+// separator.
+func normalizeHost(host string, preservePort bool) string {
+	host = utils.Trim(host, ' ')
+	if !preservePort {
+		if i := strings.LastIndexByte(host, ':'); i != -1 {
+			bracket := strings.IndexByte(host, ']')
+			isBracketedPort := len(host) > 0 && host[0] == '[' && bracket != -1 && i > bracket
+			isDomainPort := (len(host) == 0 || host[0] != '[') && strings.Count(host, ":") == 1
+			if isBracketedPort || isDomainPort {
+				host = host[:i]
+			}
+		}
+	}
+	// a trailing dot marks a fully-qualified name, it carries no meaning
+	// for matching
+	if !strings.HasPrefix(host, "[") {
+		host = utils.TrimRight(host, '.')
+	}
+	return utils.ToLower(host)
+}
+
+// built-in bodies for negotiated error responses, see Config.NegotiateErrors
+const (
+	defaultHTMLErrorTemplate = "<!DOCTYPE html><html><head><title>%code%</title></head><body><h1>%code%</h1><p>%message%</p></body></html>"
+	defaultJSONErrorTemplate = `{"status":%code%,"error":"%message%"}`
+)
+
+// negotiatedErrorResponse renders the error response in the media type the
+// request accepts, used by DefaultErrorHandler when Config.NegotiateErrors is
+// on. Bodies come from Config.ErrorTemplates, falling back to the built-in
+// templates; requests accepting neither HTML nor JSON get the plain-text
+// default.
+func negotiatedErrorResponse(c *Ctx, code int, message string) error {
+	accept := c.Accepts(MIMETextPlain, MIMETextHTML, MIMEApplicationJSON)
+	template := c.app.config.ErrorTemplates[accept]
+	contentType := accept
+	if template == "" {
+		switch accept {
+		case MIMETextHTML:
+			template = defaultHTMLErrorTemplate
+			contentType = MIMETextHTMLCharsetUTF8
+		case MIMEApplicationJSON:
+			template = defaultJSONErrorTemplate
+			contentType = MIMEApplicationJSONCharsetUTF8
+		default:
+			c.Set(HeaderContentType, MIMETextPlainCharsetUTF8)
+			return c.Status(code).SendString(message)
+		}
+	}
+	body := strings.ReplaceAll(template, "%code%", strconv.Itoa(code))
+	body = strings.ReplaceAll(body, "%message%", message)
+	c.Set(HeaderContentType, contentType)
+	return c.Status(code).SendString(body)
+}
+
+// decodeUnreservedEscapes decodes percent-escapes of RFC 3986 unreserved
+// characters (ALPHA / DIGIT / "-" / "." / "_" / "~") in place and returns the
+// shortened slice. Escapes of reserved characters like %2F are kept raw, so
+// decoding never introduces new path separators. See
+// Config.UnescapeDetectionPath.
+func decodeUnreservedEscapes(path []byte) []byte {
+	if bytes.IndexByte(path, '%') == -1 {
+		return path
+	}
+	n := 0
+	for i := 0; i < len(path); i++ {
+		b := path[i]
+		if b == '%' && i+2 < len(path) {
+			hi, lo := unhexByte(path[i+1]), unhexByte(path[i+2])
+			if hi != 16 && lo != 16 {
+				if decoded := hi<<4 | lo; isUnreservedChar(decoded) {
+					b = decoded
+					i += 2
+				}
+			}
+		}
+		path[n] = b
+		n++
+	}
+	return path[:n]
+}
+
+// unhexByte returns the value of a hex digit, or 16 for a non-hex byte
+func unhexByte(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10
+	}
+	return 16
+}
+
+// isUnreservedChar reports whether the byte is an RFC 3986 unreserved
+// character, percent-escaping these never changes a URI's meaning
+func isUnreservedChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// isUpgradeRequest reports whether the request asks for a protocol upgrade,
+// i.e. carries an Upgrade header announced in the Connection header.
+func isUpgradeRequest(c *Ctx) bool {
+	if c.Get(HeaderUpgrade) == "" {
+		return false
+	}
+	return strings.Contains(utils.ToLower(c.Get(HeaderConnection)), "upgrade")
+}
+
+// isSafeMethod reports whether the given HTTP method is considered safe,
+// i.e. it should not change state on the server
+func isSafeMethod(method string) bool {
+	return method == MethodGet || method == MethodHead
+}
+
 // uniqueRouteStack drop all not unique routes from the slice
 func uniqueRouteStack(stack []*Route) []*Route {
 	var unique []*Route
@@ -970,6 +1298,42 @@ var (
 	ErrNetworkAuthenticationRequired = NewError(StatusNetworkAuthenticationRequired) // 511
 )
 
+// ErrClientDisconnected is reported when the client connection drops while
+// the request body is being read, e.g. when a streamed upload is truncated.
+// Body reads through Ctx.BodyStream wrap their underlying network errors in
+// this sentinel, and only errors wrapping it are treated as disconnects by
+// app.handler, see Config.SkipErrorHandlerOnDisconnect. Matching the raw
+// io.EOF or connection errors instead would misclassify handler errors that
+// merely wrap them, e.g. a truncated read from a proxied upstream.
+var ErrClientDisconnected = errors.New("client disconnected during request read")
+
+// isConnectionReadError reports whether the error came out of reading the
+// client connection with the peer gone. It must only be applied to errors
+// from actual connection reads - fasthttp's request read loop or the
+// Ctx.BodyStream reader - never to arbitrary handler errors
+func isConnectionReadError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "connection reset by peer") || strings.Contains(msg, "broken pipe")
+}
+
+// disconnectAwareBody reads the request body stream and maps read errors
+// caused by the client hanging up to ErrClientDisconnected. A plain io.EOF is
+// the regular end of the body and passes through untouched
+type disconnectAwareBody struct {
+	reader io.Reader
+}
+
+func (b *disconnectAwareBody) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) && isConnectionReadError(err) {
+		err = fmt.Errorf("%w: %v", ErrClientDisconnected, err)
+	}
+	return n, err
+}
+
 // HTTP Headers were copied from net/http.
 const (
 	HeaderAuthorization                   = "Authorization"
@@ -799,3 +799,47 @@ func Benchmark_SlashRecognition(b *testing.B) {
 		utils.AssertEqual(b, true, result)
 	})
 }
+
+func Test_Utils_decodePathParam(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{value: "john", expected: "john"},
+		{value: "John%20Doe", expected: "John Doe"},
+		{value: "a%2Fb", expected: "a/b"},
+		{value: "a+b", expected: "a+b"},
+		// invalid or truncated escape sequences stay verbatim
+		{value: "John%ZZDoe", expected: "John%ZZDoe"},
+		{value: "trailing%2", expected: "trailing%2"},
+		{value: "100%", expected: "100%"},
+	}
+
+	for _, tc := range testCases {
+		utils.AssertEqual(t, tc.expected, decodePathParam(tc.value), tc.value)
+	}
+}
+
+// go test -run Test_Utils_normalizeHost
+func Test_Utils_normalizeHost(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		host         string
+		preservePort bool
+		expected     string
+	}{
+		{host: "example.com:8080", expected: "example.com"},
+		{host: "example.com:8080", preservePort: true, expected: "example.com:8080"},
+		{host: "Example.COM.", expected: "example.com"},
+		{host: "example.com.:8080", expected: "example.com"},
+		{host: "[::1]:8080", expected: "[::1]"},
+		{host: "[::1]:8080", preservePort: true, expected: "[::1]:8080"},
+		{host: "[2001:DB8::1]", expected: "[2001:db8::1]"},
+		{host: "::1", expected: "::1"},
+		{host: "localhost", expected: "localhost"},
+	}
+	for _, tc := range testCases {
+		utils.AssertEqual(t, tc.expected, normalizeHost(tc.host, tc.preservePort), tc.host)
+	}
+}
@@ -14,6 +14,7 @@ type (
 	OnShutdownHandler  = func() error
 	OnForkHandler      = func(int) error
 	OnMountHandler     = func(*App) error
+	OnBuildTreeHandler = func(routes []*Route)
 )
 
 // Hooks is a struct to use it with App.
@@ -30,6 +31,7 @@ type Hooks struct {
 	onShutdown  []OnShutdownHandler
 	onFork      []OnForkHandler
 	onMount     []OnMountHandler
+	onBuildTree []OnBuildTreeHandler
 }
 
 // ListenData is a struct to use it with OnListenHandler
@@ -207,6 +209,22 @@ func (h *Hooks) executeOnForkHooks(pid int) {
 	}
 }
 
+func (h *Hooks) executeOnBuildTreeHooks() {
+	if len(h.onBuildTree) == 0 {
+		return
+	}
+
+	// flatten the method stacks, the route pointers allow mutation
+	var routes []*Route
+	for _, stack := range h.app.stack {
+		routes = append(routes, stack...)
+	}
+
+	for _, v := range h.onBuildTree {
+		v(routes)
+	}
+}
+
 func (h *Hooks) executeOnMountHooks(app *App) error {
 	for _, v := range h.onMount {
 		if err := v(app); err != nil {
@@ -3,6 +3,7 @@ package fiber
 import (
 	"errors"
 	"fmt"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -286,3 +287,43 @@ func Test_Hook_OnMount(t *testing.T) {
 
 	app.Mount("/sub", subApp)
 }
+
+func Test_Hook_OnBuildTree(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Get("/a", func(c *Ctx) error {
+		return c.SendString("a")
+	})
+	app.Get("/b", func(c *Ctx) error {
+		return c.SendString("b")
+	})
+
+	var touched int
+	app.OnBuildTree(func(routes []*Route) {
+		for _, route := range routes {
+			route := route
+			touched++
+			// prepend a handler that tags every response
+			handler := func(c *Ctx) error {
+				c.Set("X-Tagged", route.Method)
+				return c.Next()
+			}
+			route.Handlers = append([]Handler{handler}, route.Handlers...)
+		}
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/a", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, MethodGet, resp.Header.Get("X-Tagged"))
+	utils.AssertEqual(t, true, touched > 0)
+	seen := touched
+
+	// the hook runs once per tree build, not per request
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/b", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, MethodGet, resp.Header.Get("X-Tagged"))
+	utils.AssertEqual(t, seen, touched)
+}
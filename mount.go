@@ -23,6 +23,13 @@ type mountFields struct {
 	subAppsProcessed sync.Once
 	// Prefix of app if it was mounted
 	mountPath string
+	// App the app was directly mounted to and the prefixes used, recorded so
+	// routes registered after the parent pulled this app's routes can still
+	// be propagated. One app can be mounted under several prefixes
+	parent         *App
+	parentPrefixes []string
+	// set once the app pulled its sub-apps' routes into its own stack
+	subAppsRoutesPulled bool
 }
 
 // Create empty mountFields instance
@@ -52,6 +59,10 @@ func (app *App) Mount(prefix string, subApp *App) Router {
 		app.mountFields.appList[path] = subApp
 	}
 
+	// record the direct mount relationship for late route propagation
+	subApp.mountFields.parent = app
+	subApp.mountFields.parentPrefixes = append(subApp.mountFields.parentPrefixes, prefix)
+
 	// register mounted group
 	mountGroup := &Group{Prefix: prefix, app: subApp}
 	app.register(methodUse, prefix, mountGroup)
@@ -64,6 +75,19 @@ func (app *App) Mount(prefix string, subApp *App) Router {
 	return app
 }
 
+// MountMany attaches the same app instance as a sub-router under several
+// prefixes at once, so one router can serve e.g. both "/v1" and "/v2". Each
+// prefix receives its own copy of the sub-app's routes when they are pulled
+// into the parent at startup, and routes registered on the sub-app afterwards
+// are propagated under every prefix.
+func (app *App) MountMany(prefixes []string, subApp *App) Router {
+	for _, prefix := range prefixes {
+		app.Mount(prefix, subApp)
+	}
+
+	return app
+}
+
 // Mount attaches another app instance as a sub-router along a routing path.
 // It's very useful to split up a large API as many independent routers and
 // compose them as a single service using Mount.
@@ -82,6 +106,10 @@ func (grp *Group) Mount(prefix string, subApp *App) Router {
 		grp.app.mountFields.appList[path] = subApp
 	}
 
+	// record the direct mount relationship for late route propagation
+	subApp.mountFields.parent = grp.app
+	subApp.mountFields.parentPrefixes = append(subApp.mountFields.parentPrefixes, groupPath)
+
 	// register mounted group
 	mountGroup := &Group{Prefix: groupPath, app: subApp}
 	grp.app.register(methodUse, groupPath, mountGroup)
@@ -201,10 +229,13 @@ func (app *App) processSubAppsRoutes() {
 				subAppRouteClone := app.copyRoute(subAppRoute)
 
 				// Add the parent route's path as a prefix to the sub-app's route
-				app.addPrefixToRoute(route.path, subAppRouteClone)
+				app.addPrefixToRoute(route.path, subAppRouteClone, route.group.app)
 
 				// Add the cloned sub-app's route to the slice of sub-app routes
 				subRoutes[j] = subAppRouteClone
+
+				// Imported routes bypass addRoute, notify subscribers here
+				app.notifyRouteAdd(subAppRouteClone)
 			}
 
 			// Insert the sub-app's routes into the parent app's stack
@@ -227,4 +258,8 @@ func (app *App) processSubAppsRoutes() {
 		}
 	}
 	atomic.StoreUint32(&app.handlersCount, handlersCount)
+
+	// From here on, routes added to mounted sub-apps have to be propagated
+	// into this app's stack as they are registered
+	app.mountFields.subAppsRoutesPulled = true
 }
@@ -32,6 +32,47 @@ func Test_App_Mount(t *testing.T) {
 	utils.AssertEqual(t, uint32(2), app.handlersCount)
 }
 
+// go test -run Test_App_MountMany
+func Test_App_MountMany(t *testing.T) {
+	t.Parallel()
+	micro := New()
+	micro.Get("/users", func(c *Ctx) error {
+		return c.SendString(c.Path())
+	})
+
+	app := New()
+	app.MountMany([]string{"/v1", "/v2"}, micro)
+
+	// the same routes are reachable under every prefix
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/v1/users", http.NoBody))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, 200, resp.StatusCode, "Status code")
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/v2/users", http.NoBody))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, 200, resp.StatusCode, "Status code")
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/v3/users", http.NoBody))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, 404, resp.StatusCode, "Status code")
+
+	// each prefix imported its own copy of the GET and paired HEAD route
+	utils.AssertEqual(t, uint32(4), app.handlersCount)
+
+	// late-registered routes are propagated under every prefix
+	micro.Get("/late", func(c *Ctx) error {
+		return c.SendString("late")
+	})
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/v1/late", http.NoBody))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, 200, resp.StatusCode, "Status code")
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/v2/late", http.NoBody))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, 200, resp.StatusCode, "Status code")
+}
+
 func Test_App_Mount_RootPath_Nested(t *testing.T) {
 	t.Parallel()
 	app := New()
@@ -421,8 +462,8 @@ func Test_Mount_Route_Names(t *testing.T) {
 	// create sub-app with 2 handlers inside a group:
 	subApp2 := New()
 	app2Grp := subApp2.Group("/users").Name("users.")
-	app2Grp.Get("", nil).Name("get")
-	app2Grp.Post("", nil).Name("add")
+	app2Grp.Get("", emptyHandler).Name("get")
+	app2Grp.Post("", emptyHandler).Name("add")
 
 	// put both sub-apps into root app
 	rootApp := New()
@@ -592,3 +633,105 @@ func Test_Ctx_Render_MountGroup(t *testing.T) {
 	utils.AssertEqual(t, nil, err)
 	utils.AssertEqual(t, "<h1>Hello doe!</h1>", string(body))
 }
+
+// go test -run Test_App_Mount_PreserveRoutingConfig
+func Test_App_Mount_PreserveRoutingConfig(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	subApp := New(Config{
+		CaseSensitive:                true,
+		PreserveRoutingConfigOnMount: true,
+	})
+	subApp.Get("/CaseSensitive", func(c *Ctx) error {
+		return c.SendString("ok")
+	})
+	app.Mount("/sub", subApp)
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/sub/CaseSensitive", http.NoBody))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/sub/casesensitive", http.NoBody))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_App_Mount_PreserveRoutingConfig_Disabled
+func Test_App_Mount_PreserveRoutingConfig_Disabled(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	// without the flag the sub-app adopts the parent's routing semantics
+	subApp := New(Config{CaseSensitive: true})
+	subApp.Get("/CaseSensitive", func(c *Ctx) error {
+		return c.SendString("ok")
+	})
+	app.Mount("/sub", subApp)
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/sub/casesensitive", http.NoBody))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_App_Mount_LateRoutes
+func Test_App_Mount_LateRoutes(t *testing.T) {
+	t.Parallel()
+	app := New()
+	subApp := New()
+	subApp.Get("/early", testEmptyHandler)
+	app.Mount("/sub", subApp)
+
+	// routes added after the mount call but before the first build
+	subApp.Get("/late", testEmptyHandler)
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/sub/late", http.NoBody))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// routes added after the tree was already built
+	subApp.Get("/later", testEmptyHandler)
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/sub/later", http.NoBody))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/sub/early", http.NoBody))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_App_Mount_ParamPrefix
+func Test_App_Mount_ParamPrefix(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	// versioning middleware captures the version from the prefix and hands
+	// it downstream without every route registering the param itself
+	app.Use("/v:version/*", func(c *Ctx) error {
+		c.Locals("version", c.Params("version"))
+		return c.Next()
+	})
+
+	sub := New()
+	sub.Get("/users", func(c *Ctx) error {
+		version, ok := c.Locals("version").(string)
+		utils.AssertEqual(t, true, ok)
+		return c.SendString("version " + version + ", id " + c.Params("version"))
+	})
+	app.Mount("/v:version", sub)
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/v2/users", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "version 2, id 2", string(body))
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/v3/users", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "version 3, id 3", string(body))
+}
@@ -48,13 +48,15 @@ type routeSegment struct {
 // different special routing signs
 const (
 	wildcardParam                byte = '*'  // indicates an optional greedy parameter
-	plusParam                    byte = '+'  // indicates a required greedy parameter
+	plusParam                    byte = '+'  // indicates a required greedy parameter, optionally named like "+segments"
 	optionalParam                byte = '?'  // concludes a parameter by name and makes it optional
 	paramStarterChar             byte = ':'  // start character for a parameter with name
 	slashDelimiter               byte = '/'  // separator for the route, unlike the other delimiters this character at the end can be optional
 	escapeChar                   byte = '\\' // escape character
 	paramConstraintStart         byte = '<'  // start of type constraint for a parameter
 	paramConstraintEnd           byte = '>'  // end of type constraint for a parameter
+	paramBraceStart              byte = '{'  // start of an OpenAPI-style parameter
+	paramBraceEnd                byte = '}'  // end of an OpenAPI-style parameter
 	paramConstraintSeparator     byte = ';'  // separator of type constraints for a parameter
 	paramConstraintDataStart     byte = '('  // start of data of type constraint for a parameter
 	paramConstraintDataEnd       byte = ')'  // end of data of type constraint for a parameter
@@ -173,17 +175,67 @@ func RoutePatternMatch(path, pattern string, cfg ...Config) bool {
 	return false
 }
 
+// PathParser translates a registered route pattern into its segments.
+// A custom implementation can be configured via Config.PathParser to support
+// path syntaxes from other frameworks; the built-in Fiber syntax remains the
+// default. Parameter segments become the keys Ctx.Params resolves, in the
+// order the parser returns them.
+type PathParser interface {
+	// ParsePattern splits the pattern into ordered segments. Constant
+	// segments set Const, parameter segments set Param plus the matching
+	// flags.
+	ParsePattern(pattern string) []Segment
+}
+
+// parsePattern runs the configured PathParser, falling back to the built-in
+// route syntax. Custom segments are converted into the internal representation
+// and enriched with the same matching metadata parseRoute computes.
+func (app *App) parsePattern(pattern string) routeParser {
+	custom := app.config.PathParser
+	if custom == nil {
+		return parseRoute(pattern)
+	}
+	parser := routeParser{}
+	for _, segment := range custom.ParsePattern(pattern) {
+		seg := &routeSegment{
+			Const:      segment.Const,
+			IsParam:    segment.IsParam,
+			ParamName:  segment.Param,
+			IsGreedy:   segment.IsGreedy,
+			IsOptional: segment.IsOptional,
+		}
+		if seg.IsParam {
+			parser.params = append(parser.params, seg.ParamName)
+		} else {
+			// the matcher consumes const segments by their length
+			seg.Length = len(seg.Const)
+		}
+		parser.segs = append(parser.segs, seg)
+	}
+	if len(parser.segs) > 0 {
+		parser.segs[len(parser.segs)-1].IsLast = true
+	}
+	parser.segs = addParameterMetaInfo(parser.segs)
+	return parser
+}
+
 // parseRoute analyzes the route and divides it into segments for constant areas and parameters,
 // this information is needed later when assigning the requests to the declared routes
 func parseRoute(pattern string) routeParser {
 	parser := routeParser{}
 
+	// translate OpenAPI-style "{name}" parameters into the native ":name" syntax
+	pattern = replaceBraceParams(pattern)
+
 	part := ""
 	for len(pattern) > 0 {
 		nextParamPosition := findNextParamPosition(pattern)
 		// handle the parameter part
 		if nextParamPosition == 0 {
-			processedPart, seg := parser.analyseParameterPart(pattern)
+			// a parameter at the start of a fresh segment may carry a name
+			// after a plus sign, mid-segment ones never do
+			isSegmentStart := part == "" || part[len(part)-1] == slashDelimiter
+			processedPart, seg := parser.analyseParameterPart(pattern, isSegmentStart)
 			parser.params, parser.segs, part = append(parser.params, seg.ParamName), append(parser.segs, seg), processedPart
 		} else {
 			processedPart, seg := parser.analyseConstantPart(pattern, nextParamPosition)
@@ -205,6 +257,56 @@ func parseRoute(pattern string) routeParser {
 	return parser
 }
 
+// replaceBraceParams translates OpenAPI-style "{name}" parameters into the
+// native ":name" syntax, so both styles can be mixed within one route
+// pattern and Route.Params records the bare names either way. Braces inside
+// constraint sections (e.g. regex quantifiers) and escaped braces are left
+// untouched.
+func replaceBraceParams(pattern string) string {
+	if strings.IndexByte(pattern, paramBraceStart) == -1 {
+		return pattern
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(pattern))
+	var inConstraint bool
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		// keep escaped characters as they are
+		if ch == escapeChar && i+1 < len(pattern) {
+			sb.WriteByte(ch)
+			sb.WriteByte(pattern[i+1])
+			i++
+			continue
+		}
+		// don't touch braces inside a constraint part, e.g. regex quantifiers
+		if ch == paramConstraintStart {
+			inConstraint = true
+		} else if ch == paramConstraintEnd {
+			inConstraint = false
+		}
+		if ch == paramBraceStart && !inConstraint {
+			// find the closing brace within the current segment
+			end := -1
+			for j := i + 1; j < len(pattern) && pattern[j] != slashDelimiter; j++ {
+				if pattern[j] == paramBraceEnd {
+					end = j
+					break
+				}
+			}
+			if end > i+1 {
+				sb.WriteByte(paramStarterChar)
+				sb.WriteString(pattern[i+1 : end])
+				i = end
+				continue
+			}
+		}
+		sb.WriteByte(ch)
+	}
+
+	return sb.String()
+}
+
 // addParameterMetaInfo add important meta information to the parameter segments
 // to simplify the search for the end of the parameter
 func addParameterMetaInfo(segs []*routeSegment) []*routeSegment {
@@ -284,7 +386,7 @@ func (*routeParser) analyseConstantPart(pattern string, nextParamPosition int) (
 }
 
 // analyseParameterPart find the parameter end and create the route segment
-func (routeParser *routeParser) analyseParameterPart(pattern string) (string, *routeSegment) {
+func (routeParser *routeParser) analyseParameterPart(pattern string, isSegmentStart bool) (string, *routeSegment) {
 	isWildCard := pattern[0] == wildcardParam
 	isPlusParam := pattern[0] == plusParam
 
@@ -297,9 +399,12 @@ func (routeParser *routeParser) analyseParameterPart(pattern string) (string, *r
 
 	parameterConstraintStart := -1
 	parameterConstraintEnd := -1
+	// a plus followed by an identifier is a named one-or-more capture like
+	// "+segments", its name is consumed like a regular parameter name
+	isNamedPlus := isPlusParam && isSegmentStart && len(pattern) > 1 && !isInCharset(pattern[1], parameterEndChars)
 	// handle wildcard end
 	switch {
-	case isWildCard, isPlusParam:
+	case isWildCard, isPlusParam && !isNamedPlus:
 		parameterEndPosition = 0
 	case parameterEndPosition == -1:
 		parameterEndPosition = len(pattern) - 1
@@ -369,8 +474,14 @@ func (routeParser *routeParser) analyseParameterPart(pattern string) (string, *r
 		routeParser.wildCardCount++
 		paramName += strconv.Itoa(routeParser.wildCardCount)
 	} else if isPlusParam {
-		routeParser.plusCount++
-		paramName += strconv.Itoa(routeParser.plusCount)
+		if isNamedPlus {
+			// the captured segments land under the bare name, split into
+			// a slice via Ctx.ParamsSlice
+			paramName = paramName[1:]
+		} else {
+			routeParser.plusCount++
+			paramName += strconv.Itoa(routeParser.plusCount)
+		}
 	}
 
 	segment := &routeSegment{
@@ -475,7 +586,10 @@ func splitNonEscaped(s, sep string) []string {
 	return append(result, s)
 }
 
-// getMatch parses the passed url and tries to match it against the route segments and determine the parameter positions
+// getMatch parses the passed url and tries to match it against the route segments and determine the parameter positions.
+// Offsets are computed on detectionPath (the case/strict normalized path) while parameter values are sliced
+// from path, so captured params keep the original request casing even under case-insensitive routing.
+// Both strings therefore have to stay structurally identical.
 func (routeParser *routeParser) getMatch(detectionPath, path string, params *[maxParams]string, partialCheck bool) bool { //nolint: revive // Accepting a bool param is fine here
 	var i, paramsIterator, partLen int
 	for _, segment := range routeParser.segs {
@@ -6,6 +6,9 @@ package fiber
 
 import (
 	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2/utils"
@@ -107,6 +110,15 @@ func Test_Path_parseRoute(t *testing.T) {
 		plusCount: 1,
 	}, rp)
 
+	rp = parseRoute("/tree/+segments")
+	utils.AssertEqual(t, routeParser{
+		segs: []*routeSegment{
+			{Const: "/tree/", Length: 6},
+			{IsParam: true, ParamName: "segments", IsGreedy: true, IsOptional: false, IsLast: true},
+		},
+		params: []string{"segments"},
+	}, rp)
+
 	rp = parseRoute("/api/:day.:month?.:year?")
 	utils.AssertEqual(t, routeParser{
 		segs: []*routeSegment{
@@ -266,3 +278,90 @@ func Benchmark_RoutePatternMatch(t *testing.B) {
 		benchCaseFn(testCollection)
 	}
 }
+
+// angleBracketParser parses "<name:type>" style parameters for the custom
+// PathParser tests, the type part is ignored
+type angleBracketParser struct{}
+
+func (angleBracketParser) ParsePattern(pattern string) []Segment {
+	var segments []Segment
+	for len(pattern) > 0 {
+		start := strings.IndexByte(pattern, '<')
+		if start == -1 {
+			segments = append(segments, Segment{Const: pattern})
+			break
+		}
+		if start > 0 {
+			segments = append(segments, Segment{Const: pattern[:start]})
+		}
+		end := strings.IndexByte(pattern, '>')
+		name := pattern[start+1 : end]
+		if colon := strings.IndexByte(name, ':'); colon != -1 {
+			name = name[:colon]
+		}
+		segments = append(segments, Segment{Param: name, IsParam: true})
+		pattern = pattern[end+1:]
+	}
+	return segments
+}
+
+// go test -run Test_Path_NamedPlusParam
+func Test_Path_NamedPlusParam(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/tree/+segments", func(c *Ctx) error {
+		return c.SendString(strings.Join(c.ParamsSlice("segments"), ","))
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/tree/a/b/c", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "a,b,c", string(body))
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/tree/a", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "a", string(body))
+
+	// unlike "*", a named "+" requires at least one segment
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/tree/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+}
+
+// go test -run Test_Path_CustomPathParser
+func Test_Path_CustomPathParser(t *testing.T) {
+	t.Parallel()
+	app := New(Config{PathParser: angleBracketParser{}})
+	app.Get("/users/<id:int>/posts/<slug>", func(c *Ctx) error {
+		return c.SendString(c.Params("id") + "|" + c.Params("slug"))
+	})
+	app.Get("/plain", func(c *Ctx) error {
+		return c.SendString("plain")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/users/42/posts/hello", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "42|hello", string(body))
+
+	// Constant routes keep matching through the custom parser
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/plain", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	// Param names are reported like with the built-in syntax
+	var route Route
+	for _, r := range app.GetRoutes() {
+		if r.Method == MethodGet && strings.HasPrefix(r.Path, "/users") {
+			route = r
+		}
+	}
+	utils.AssertEqual(t, []string{"id", "slug"}, route.Params)
+}
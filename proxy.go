@@ -0,0 +1,80 @@
+// ⚡️ Fiber is an Express inspired web framework written in Go with ☕️
+// 🤖 Github Repository: https://github.com/gofiber/fiber
+// 📌 API Documentation: https://docs.gofiber.io
+
+package fiber
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2/utils"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Proxy registers a wildcard route that forwards matching requests to the
+// given upstream, preserving method, headers and body, and streams the
+// response back. The captured wildcard tail plus the query string are
+// appended to the upstream address:
+//
+//	app.Proxy("/upstream/*", "http://backend:8080")
+//
+// forwards "/upstream/foo?x=1" to "http://backend:8080/foo?x=1". The Host
+// header is rewritten to the upstream host and X-Forwarded-For, -Host and
+// -Proto headers are set for the upstream. Requests time out with
+// Config.ReadTimeout, unlimited when zero. The routes are registered for
+// all methods and show up in GetRoutes like any other route.
+func (app *App) Proxy(prefix, upstream string) Router {
+	target := utils.TrimRight(upstream, '/')
+	// static part of the pattern before the wildcard, e.g. "/upstream/"
+	base := prefix
+	if idx := strings.IndexByte(prefix, '*'); idx >= 0 {
+		base = prefix[:idx]
+	}
+	client := &fasthttp.Client{
+		NoDefaultUserAgentHeader: true,
+		DisablePathNormalizing:   true,
+	}
+
+	handler := func(c *Ctx) error {
+		req := &c.fasthttp.Request
+		resp := &c.fasthttp.Response
+
+		// map the wildcard tail to the upstream path, taken verbatim from the
+		// original request URI - Params("*") is percent-decoded, which would
+		// turn an encoded "%2F" into a path separator and an encoded "%3F"
+		// into a query delimiter on the upstream
+		tail := c.Params("*")
+		if raw := c.pathOriginal; len(raw) >= len(base) && utils.EqualFold(raw[:len(base)], base) {
+			tail = raw[len(base):]
+		}
+		url := target + "/" + tail
+		if qs := c.app.getString(c.Context().URI().QueryString()); qs != "" {
+			url += "?" + qs
+		}
+
+		// let the upstream know who asked
+		req.Header.Set(HeaderXForwardedFor, c.IP())
+		req.Header.Set(HeaderXForwardedHost, c.Hostname())
+		req.Header.Set(HeaderXForwardedProto, c.Protocol())
+		// hop-by-hop headers are not forwarded
+		req.Header.Del(HeaderConnection)
+		// the Host header is derived from the upstream address
+		req.SetRequestURI(url)
+
+		var err error
+		if timeout := c.app.config.ReadTimeout; timeout > 0 {
+			err = client.DoTimeout(req, resp, timeout)
+		} else {
+			err = client.Do(req, resp)
+		}
+		if err != nil {
+			return ErrBadGateway
+		}
+		resp.Header.Del(HeaderConnection)
+
+		return nil
+	}
+
+	return app.All(prefix, handler)
+}
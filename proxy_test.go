@@ -0,0 +1,93 @@
+// ⚡️ Fiber is an Express inspired web framework written in Go with ☕️
+// 📃 Github Repository: https://github.com/gofiber/fiber
+// 📌 API Documentation: https://docs.gofiber.io
+
+//nolint:bodyclose // Much easier to just ignore memory leaks in tests
+package fiber
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+func Test_App_Proxy(t *testing.T) {
+	t.Parallel()
+
+	// stub upstream echoing back method, path, query and body
+	upstream := New(Config{DisableStartupMessage: true})
+	upstream.All("/*", func(c *Ctx) error {
+		return c.SendString(c.Method() + " /" + c.Params("*") + "?" + string(c.Context().URI().QueryString()) + " " + string(c.Body()))
+	})
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() {
+		utils.AssertEqual(t, nil, upstream.Listener(ln))
+	}()
+	defer func() {
+		utils.AssertEqual(t, nil, upstream.ShutdownWithTimeout(time.Second))
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+
+	app := New()
+	app.Proxy("/upstream/*", "http://"+ln.Addr().String())
+
+	// GET is forwarded with path and query intact
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/upstream/foo/bar?x=1", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "GET /foo/bar?x=1 ", string(body))
+
+	// POST bodies pass through
+	resp, err = app.Test(httptest.NewRequest(MethodPost, "/upstream/submit", bytes.NewBufferString("payload")))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "POST /submit? payload", string(body))
+
+	// the proxy routes are part of the route table
+	utils.AssertEqual(t, true, len(app.GetRoutes(true)) > 0)
+}
+
+func Test_App_Proxy_RawPath(t *testing.T) {
+	t.Parallel()
+
+	// stub upstream echoing back the raw, undecoded request path
+	upstream := New(Config{DisableStartupMessage: true})
+	upstream.All("/*", func(c *Ctx) error {
+		return c.SendString(string(c.Context().URI().PathOriginal()))
+	})
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() {
+		utils.AssertEqual(t, nil, upstream.Listener(ln))
+	}()
+	defer func() {
+		utils.AssertEqual(t, nil, upstream.ShutdownWithTimeout(time.Second))
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+
+	app := New()
+	app.Proxy("/upstream/*", "http://"+ln.Addr().String())
+
+	// encoded separators stay encoded on the way upstream instead of being
+	// decoded into path traversal or a query delimiter
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/upstream/a%2F..%2Fb%3Fc", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "/a%2F..%2Fb%3Fc", string(body))
+}
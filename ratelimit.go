@@ -0,0 +1,102 @@
+// ⚡️ Fiber is an Express inspired web framework written in Go with ☕️
+// 🤖 Github Repository: https://github.com/gofiber/fiber
+// 📌 API Documentation: https://docs.gofiber.io
+
+package fiber
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitStore is the storage used by per-route rate limiting. Increment
+// increases the hit counter for the given key and returns the new count
+// together with the remaining time until the counter expires; a fresh key
+// starts a new window of the given duration. Implementations must be safe
+// for concurrent use, e.g. a Redis-backed store for multi-instance setups.
+type RateLimitStore interface {
+	Increment(key string, window time.Duration) (count int, ttl time.Duration, err error)
+}
+
+// memoryRateLimitStore is the default in-memory RateLimitStore.
+type memoryRateLimitStore struct {
+	mutex   sync.Mutex
+	entries map[string]*rateLimitEntry
+}
+
+type rateLimitEntry struct {
+	count   int
+	expires time.Time
+}
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{
+		entries: make(map[string]*rateLimitEntry),
+	}
+}
+
+// Increment implements RateLimitStore with a fixed window per key.
+func (s *memoryRateLimitStore) Increment(key string, window time.Duration) (int, time.Duration, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expires) {
+		entry = &rateLimitEntry{expires: now.Add(window)}
+		s.entries[key] = entry
+	}
+	entry.count++
+
+	return entry.count, entry.expires.Sub(now), nil
+}
+
+// RateLimit attaches a rate-limit policy to the latest registered route,
+// allowing at most max requests per client IP within the given window:
+//
+//	app.Post("/login", handler).RateLimit(5, time.Minute)
+//
+// Exceeding requests are rejected with 429 Too Many Requests and a
+// Retry-After header before the route's handlers run. The counter state
+// lives in Config.RateLimitStore, an in-memory store by default.
+func (app *App) RateLimit(max int, window time.Duration) Router {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			isMethodValid := route.Method == app.latestRoute.Method || app.latestRoute.use ||
+				(app.latestRoute.Method == MethodGet && route.Method == MethodHead)
+
+			if route.Path == app.latestRoute.Path && isMethodValid {
+				route.rateLimitMax = max
+				route.rateLimitWindow = window
+			}
+		}
+	}
+
+	return app
+}
+
+// RateLimit attaches a rate-limit policy to the latest registered route.
+func (grp *Group) RateLimit(max int, window time.Duration) Router {
+	grp.app.RateLimit(max, window)
+
+	return grp
+}
+
+// enforceRateLimit counts the request against the route's rate limit and
+// returns ErrTooManyRequests with a Retry-After header when it is exceeded
+func (app *App) enforceRateLimit(c *Ctx, route *Route) error {
+	count, ttl, err := app.config.RateLimitStore.Increment(c.IP()+":"+route.Method+":"+route.path, route.rateLimitWindow)
+	if err != nil {
+		return err
+	}
+	if count > route.rateLimitMax {
+		c.Set(HeaderRetryAfter, strconv.FormatFloat(math.Ceil(ttl.Seconds()), 'f', -1, 64))
+		return ErrTooManyRequests
+	}
+	return nil
+}
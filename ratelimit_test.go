@@ -0,0 +1,65 @@
+// ⚡️ Fiber is an Express inspired web framework written in Go with ☕️
+// 📃 Github Repository: https://github.com/gofiber/fiber
+// 📌 API Documentation: https://docs.gofiber.io
+
+//nolint:bodyclose // Much easier to just ignore memory leaks in tests
+package fiber
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+func Test_Route_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Post("/login", func(c *Ctx) error {
+		return c.SendString("ok")
+	}).RateLimit(2, time.Minute)
+	app.Post("/free", func(c *Ctx) error {
+		return c.SendString("free")
+	})
+
+	// requests within the limit pass
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(httptest.NewRequest(MethodPost, "/login", nil))
+		utils.AssertEqual(t, nil, err, "app.Test(req)")
+		utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	}
+
+	// the request exceeding the limit is rejected with a Retry-After header
+	resp, err := app.Test(httptest.NewRequest(MethodPost, "/login", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusTooManyRequests, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, true, resp.Header.Get(HeaderRetryAfter) != "", "Retry-After header")
+
+	// unlimited routes are unaffected
+	resp, err = app.Test(httptest.NewRequest(MethodPost, "/free", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
+func Test_MemoryRateLimitStore_WindowReset(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryRateLimitStore()
+
+	count, ttl, err := store.Increment("key", 50*time.Millisecond)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, 1, count)
+	utils.AssertEqual(t, true, ttl > 0)
+
+	count, _, err = store.Increment("key", 50*time.Millisecond)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, 2, count)
+
+	// the counter starts over once the window expired
+	time.Sleep(60 * time.Millisecond)
+	count, _, err = store.Increment("key", 50*time.Millisecond)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, 1, count)
+}
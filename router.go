@@ -5,14 +5,17 @@
 package fiber
 
 import (
+	"errors"
 	"fmt"
 	"html"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/gofiber/fiber/v2/log"
 	"github.com/gofiber/fiber/v2/utils"
 
 	"github.com/valyala/fasthttp"
@@ -43,20 +46,81 @@ type Router interface {
 	Mount(prefix string, fiber *App) Router
 
 	Name(name string) Router
+
+	CacheControl(value string) Router
+
+	RateLimit(max int, window time.Duration) Router
+
+	Timeouts(read, write time.Duration) Router
+
+	SingleFlight(keyFn func(*Ctx) string) Router
+
+	SkipMiddleware() Router
+
+	Scheme(scheme string) Router
+
+	Consumes(types ...string) Router
+
+	DisableCompression() Router
+
+	Validate(schema interface{}) Router
+
+	When(predicate func(*Ctx) bool) Router
+
+	Query(key, value string) Router
+
+	Attr(key, value string) Router
+
+	LogAs(name string) Router
+
+	AllowUpgrade() Router
+
+	Related(rel, routeName string) Router
+
+	AddExact(method, path string, handlers ...Handler) Router
+
+	AddExactMethod(method, path string, handlers ...Handler) Router
 }
 
 // Route is a struct that holds all metadata for each registered handler.
 type Route struct {
 	// ### important: always keep in sync with the copy method "app.copyRoute" ###
 	// Data for routing
-	pos         uint32      // Position in stack -> important for the sort of the matched routes
-	use         bool        // USE matches path prefixes
-	mount       bool        // Indicated a mounted app on a specific route
-	star        bool        // Path equals '*'
-	root        bool        // Path equals '/'
-	path        string      // Prettified path
-	routeParser routeParser // Parameter parser
-	group       *Group      // Group instance. used for routes in groups
+	pos          uint32      // Position in stack -> important for the sort of the matched routes
+	use          bool        // USE matches path prefixes
+	mount        bool        // Indicated a mounted app on a specific route
+	star         bool        // Path equals '*'
+	root         bool        // Path equals '/'
+	path         string      // Prettified path
+	routeParser  routeParser // Parameter parser
+	group        *Group      // Group instance. used for routes in groups
+	cacheControl string      // Cache-Control directive applied after the handler ran
+	scheme       string      // Request scheme the route is restricted to, empty matches any
+	consumes     []string    // Accepted Content-Type values, empty accepts any
+
+	compressionDisabled bool              // Compression middleware should skip this route, see CompressionDisabled
+	predicate           func(*Ctx) bool   // Optional per-route match predicate, see When
+	queryConstraints    map[string]string // Required query param values, see Query
+	attrConstraints     map[string]string // Required Ctx attribute values, see Attr
+	loosePrefixMatch    bool              // Middleware prefix may end mid-segment, see Config.EnableLooseMiddlewarePrefix
+	pinned              bool              // Position is rebased to the front after registration, see UseFirst
+	compiledChain       Handler           // Handler chain folded into one function at tree build time, see Config.EnableCompiledHandlerChains
+	logFormat           string            // Name of the access log format rendered after the request, see LogAs
+	disabled            int32             // Accessed atomically, non-zero while toggled off via SetRouteEnabled
+	allowsUpgrade       bool              // Route handles protocol upgrades itself, see AllowUpgrade
+	related             []routeRelation   // Link header relations emitted after the handler ran, see Related
+
+	rateLimitMax    int           // Maximum number of requests per client within the window
+	rateLimitWindow time.Duration // Rate-limit window, 0 disables limiting
+
+	readTimeout  time.Duration // Per-route connection read deadline override, see Timeouts
+	writeTimeout time.Duration // Per-route connection write deadline override, see Timeouts
+
+	singleFlightKey func(*Ctx) string // Coalescing key for concurrent identical requests, see SingleFlight
+	skipMiddleware  bool              // Route runs without any use middleware, see SkipMiddleware
+
+	caseSensitive bool // Mounted route preserving its own case-sensitive matching
+	strictRouting bool // Mounted route preserving its own trailing-slash strictness
 
 	// Public fields
 	Method string `json:"method"` // HTTP method
@@ -67,7 +131,116 @@ type Route struct {
 	Handlers []Handler `json:"-"`      // Ctx handlers
 }
 
+// routeRelation ties a route to a named route under an RFC 8288 relation
+// type, declared via Related and emitted as a Link header
+type routeRelation struct {
+	rel       string // relation type, e.g. "next" or "prev"
+	routeName string // name of the related route
+}
+
+// sortByQuerySpecificity reorders routes that share a path so the ones with
+// more query constraints are tried first, falling back to registration order
+// on equal counts. Only the position slots the path already occupies are
+// reshuffled, routes on other paths keep their order. A no-op for route
+// tables without query constraints.
+func sortByQuerySpecificity(routes []*Route) {
+	constrained := false
+	for _, route := range routes {
+		if len(route.queryConstraints) > 0 {
+			constrained = true
+			break
+		}
+	}
+	if !constrained {
+		return
+	}
+
+	byPath := make(map[string][]int)
+	for i, route := range routes {
+		byPath[route.path] = append(byPath[route.path], i)
+	}
+	for _, indexes := range byPath {
+		if len(indexes) < 2 {
+			continue
+		}
+		group := make([]*Route, len(indexes))
+		for k, i := range indexes {
+			group[k] = routes[i]
+		}
+		sort.SliceStable(group, func(i, j int) bool {
+			return len(group[i].queryConstraints) > len(group[j].queryConstraints)
+		})
+		for k, i := range indexes {
+			routes[i] = group[k]
+		}
+	}
+}
+
+// Segment is a read-only view of one parsed piece of a route pattern,
+// exposed for tooling like OpenAPI path generation, see Route.Segments.
+type Segment struct {
+	// Constant part of the pattern, empty for parameters
+	Const string `json:"const"`
+	// Parameter name, empty for constant segments; wildcard and plus
+	// parameters carry the same generated names as Route.Params ("*1", "+1")
+	Param string `json:"param"`
+	// IsParam reports whether the segment captures a value
+	IsParam bool `json:"is_param"`
+	// IsGreedy reports whether the parameter may span multiple path segments
+	IsGreedy bool `json:"is_greedy"`
+	// IsOptional reports whether the parameter may be absent
+	IsOptional bool `json:"is_optional"`
+	// IsLast reports whether this is the final segment of the pattern
+	IsLast bool `json:"is_last"`
+}
+
+// Segments returns the parsed segments of the route pattern in order. The
+// returned slice is a copy, mutating it does not affect routing.
+func (r Route) Segments() []Segment {
+	segments := make([]Segment, len(r.routeParser.segs))
+	for i, seg := range r.routeParser.segs {
+		segments[i] = Segment{
+			Const:      seg.Const,
+			Param:      seg.ParamName,
+			IsParam:    seg.IsParam,
+			IsGreedy:   seg.IsGreedy,
+			IsOptional: seg.IsOptional,
+			IsLast:     seg.IsLast,
+		}
+	}
+	return segments
+}
+
+// CompressionDisabled reports whether the route opted out of response
+// compression via DisableCompression. Compression middlewares are expected
+// to check this on the matched route (c.Route()) and pass the response
+// through untouched when it returns true.
+func (r Route) CompressionDisabled() bool {
+	return r.compressionDisabled
+}
+
+// DetectionPath returns the normalized path the router compares against the
+// request during matching, i.e. the registered Path after CaseSensitive and
+// StrictRouting normalization and escape-char removal. Useful for tooling
+// that needs to see exactly what string is matched, next to the original
+// registered Path.
+func (r Route) DetectionPath() string {
+	return r.path
+}
+
 func (r *Route) match(detectionPath, path string, params *[maxParams]string) bool {
+	// routes of sub-apps that preserved their own routing semantics are
+	// matched against the request path normalized with their own config
+	// instead of the parent's detection path
+	if r.caseSensitive || r.strictRouting {
+		detectionPath = path
+		if !r.caseSensitive {
+			detectionPath = utils.ToLower(detectionPath)
+		}
+		if !r.strictRouting && len(detectionPath) > 1 {
+			detectionPath = utils.TrimRight(detectionPath, '/')
+		}
+	}
 	// root detectionPath check
 	if r.root && detectionPath == "/" {
 		return true
@@ -92,7 +265,14 @@ func (r *Route) match(detectionPath, path string, params *[maxParams]string) boo
 	if r.use {
 		// Single slash will match or detectionPath prefix
 		if r.root || strings.HasPrefix(detectionPath, r.path) {
-			return true
+			if r.root || r.loosePrefixMatch {
+				return true
+			}
+			// The prefix must end on a path-segment boundary, otherwise
+			// middleware at "/api" would also run for "/apiary"
+			if len(detectionPath) == len(r.path) || detectionPath[len(r.path)] == '/' || r.path[len(r.path)-1] == '/' {
+				return true
+			}
 		}
 		// Check for a simple detectionPath match
 	} else if len(r.path) == len(detectionPath) && r.path == detectionPath {
@@ -102,14 +282,61 @@ func (r *Route) match(detectionPath, path string, params *[maxParams]string) boo
 	return false
 }
 
+// MatchTraceEntry records a single route that was tested while matching a
+// request.
+type MatchTraceEntry struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Matched bool   `json:"matched"`
+	Reason  string `json:"reason"`
+}
+
+// MatchTrace records which tree bucket was selected for a request and every
+// route that was tested against it, in order. It is only populated when
+// Config.EnableMatchTrace is on.
+type MatchTrace struct {
+	Bucket  string            `json:"bucket"`
+	Entries []MatchTraceEntry `json:"entries"`
+}
+
+// matchFailReason explains for a match trace why match returned false
+func (r *Route) matchFailReason() string {
+	switch {
+	case len(r.Params) > 0:
+		return "param pattern mismatch"
+	case r.use:
+		return "prefix mismatch"
+	default:
+		return "exact path mismatch"
+	}
+}
+
 func (app *App) next(c *Ctx) (bool, error) {
 	// Get stack length
-	tree, ok := app.treeStack[c.methodINT][c.treePath]
+	// load the tree stack once, a concurrent rebuild may swap it mid-request
+	treeStack := app.getTreeStack()
+	tree, ok := treeStack[c.methodINT][c.treePath]
 	if !ok {
-		tree = app.treeStack[c.methodINT][""]
+		tree = treeStack[c.methodINT][""]
 	}
 	lenTree := len(tree) - 1
 
+	// Lazily start a match trace when enabled, c.matchTrace stays nil
+	// otherwise so the checks below are a single pointer comparison
+	if app.config.EnableMatchTrace && c.matchTrace == nil {
+		bucket := c.treePath
+		if !ok {
+			bucket = ""
+		}
+		c.matchTrace = &MatchTrace{Bucket: bucket}
+	}
+
+	// Resolve once per routing pass whether the terminal route opted out
+	// of middleware, so the scan below can skip every use route for it
+	if app.hasSkipMiddlewareRoutes && c.indexRoute == -1 {
+		c.skipMiddleware = terminalRouteSkipsMiddleware(c, tree)
+	}
+
 	// Loop over the route stack starting from previous index
 	for c.indexRoute < lenTree {
 		// Increment route index
@@ -124,27 +351,173 @@ func (app *App) next(c *Ctx) (bool, error) {
 		if route.mount {
 			continue
 		}
+		// middleware does not run for skip-marked terminal routes
+		if route.use && c.skipMiddleware {
+			if c.matchTrace != nil {
+				c.matchTrace.Entries = append(c.matchTrace.Entries, MatchTraceEntry{
+					Method: route.Method, Path: route.Path, Reason: "middleware skipped",
+				})
+			}
+			continue
+		}
+		// skip routes toggled off at runtime
+		if atomic.LoadInt32(&route.disabled) != 0 {
+			if c.matchTrace != nil {
+				c.matchTrace.Entries = append(c.matchTrace.Entries, MatchTraceEntry{
+					Method: route.Method, Path: route.Path, Reason: "route disabled",
+				})
+			}
+			continue
+		}
 
 		// Check if it matches the request path
 		match = route.match(c.detectionPath, c.path, &c.values)
 		if !match {
+			if c.matchTrace != nil {
+				c.matchTrace.Entries = append(c.matchTrace.Entries, MatchTraceEntry{
+					Method: route.Method, Path: route.Path, Reason: route.matchFailReason(),
+				})
+			}
 			// No match, next route
 			continue
 		}
+		// Routes guarded by a predicate only match when it approves the
+		// request, otherwise scanning continues as if the path mismatched
+		if route.predicate != nil && !route.predicate(c) {
+			if c.matchTrace != nil {
+				c.matchTrace.Entries = append(c.matchTrace.Entries, MatchTraceEntry{
+					Method: route.Method, Path: route.Path, Reason: "predicate rejected",
+				})
+			}
+			continue
+		}
+		// Routes with query constraints only match requests satisfying them
+		if len(route.queryConstraints) > 0 && !c.queryConstraintsMatch(route) {
+			if c.matchTrace != nil {
+				c.matchTrace.Entries = append(c.matchTrace.Entries, MatchTraceEntry{
+					Method: route.Method, Path: route.Path, Reason: "query constraint mismatch",
+				})
+			}
+			continue
+		}
+		// Routes constrained on request attributes only match when the
+		// attributes resolved by the pre-route hooks carry the same values
+		if len(route.attrConstraints) > 0 && !c.attrConstraintsMatch(route) {
+			if c.matchTrace != nil {
+				c.matchTrace.Entries = append(c.matchTrace.Entries, MatchTraceEntry{
+					Method: route.Method, Path: route.Path, Reason: "attribute mismatch",
+				})
+			}
+			continue
+		}
+		// Skip routes restricted to another request scheme. A https-only
+		// route is remembered so the plain request can still be redirected
+		// after the scan, unless another route serves its scheme
+		if route.scheme != "" && route.scheme != c.Protocol() {
+			if route.scheme == schemeHTTPS && !app.config.DisableSchemeRedirect {
+				c.schemeRedirect = true
+			}
+			if c.matchTrace != nil {
+				c.matchTrace.Entries = append(c.matchTrace.Entries, MatchTraceEntry{
+					Method: route.Method, Path: route.Path, Reason: "scheme mismatch",
+				})
+			}
+			// strict mode, pretend the route does not exist
+			continue
+		}
+		if c.matchTrace != nil {
+			c.matchTrace.Entries = append(c.matchTrace.Entries, MatchTraceEntry{
+				Method: route.Method, Path: route.Path, Matched: true, Reason: "matched",
+			})
+		}
+
+		// Routes declaring accepted content types only match requests
+		// carrying one of them, so several routes on one path dispatch on
+		// the request's Content-Type. A request whose body matched no
+		// candidate is rejected with a 415 after the scan
+		if len(route.consumes) > 0 {
+			contentType := c.Get(HeaderContentType)
+			noBody := contentType == "" && len(c.fasthttp.Request.Body()) == 0
+			if (!noBody || app.config.ConsumesRequireContentType) && !consumesMatch(contentType, route.consumes) {
+				c.consumesMismatch = true
+				if c.matchTrace != nil {
+					c.matchTrace.Entries = append(c.matchTrace.Entries, MatchTraceEntry{
+						Method: route.Method, Path: route.Path, Reason: "content type mismatch",
+					})
+				}
+				continue
+			}
+		}
+
 		// Pass route reference and param values
 		c.route = route
 
 		// Non use handler matched
 		if !c.matched && !route.use {
 			c.matched = true
+
+			// Upgrade requests landing on routes that do not handle the
+			// upgrade themselves are passed to the configured hook
+			if handler := app.config.UpgradeHandler; handler != nil && !route.allowsUpgrade && isUpgradeRequest(c) {
+				return true, handler(c)
+			}
+		}
+
+		// Enforce the route's rate limit before running its handlers
+		if route.rateLimitMax > 0 {
+			if err = app.enforceRateLimit(c, route); err != nil {
+				return match, err
+			}
+		}
+
+		// Coalesce concurrent identical requests into one execution
+		if route.singleFlightKey != nil && !route.use {
+			if key := route.singleFlightKey(c); key != "" {
+				return match, app.singleFlightDo(c, route, key)
+			}
 		}
 
 		// Execute first handler of route
-		c.indexHandler = 0
-		if len(route.Handlers) > 0 {
-			err = route.Handlers[0](c)
+		return match, app.runRoute(c, route) // Stop scanning the stack
+	}
+
+	// A https-only route would have handled the path and no other route
+	// served the plain scheme, send the request to its https equivalent
+	if !c.matched && c.schemeRedirect {
+		return true, c.Redirect(schemeHTTPS+"://"+c.Hostname()+c.OriginalURL(), StatusMovedPermanently)
+	}
+
+	// A route would have handled the path if the request carried one of
+	// its accepted content types, reject the body instead of pretending
+	// the path does not exist
+	if !c.matched && c.consumesMismatch {
+		return true, ErrUnsupportedMediaType
+	}
+
+	// Answer unhandled OPTIONS requests with the Allow set of the path,
+	// including methods contributed by param and wildcard routes
+	if app.config.EnableAutoOptions && c.method == MethodOptions && !c.matched {
+		if methods := app.allowedMethods(c.pathOriginal); len(methods) > 0 {
+			c.Set(HeaderAllow, strings.Join(methods, ", "))
+			return true, c.SendStatus(StatusNoContent)
 		}
-		return match, err // Stop scanning the stack
+	}
+
+	// Probing HEAD requests on paths that only exist under other methods
+	// are answered with an empty 200 confirming the resource's existence.
+	// Paths whose GET registered a paired HEAD route never get here, the
+	// pair already matched above
+	if app.config.EnableAutoHead && c.method == MethodHead && !c.matched {
+		if methods := app.allowedMethods(c.pathOriginal); len(methods) > 0 {
+			return true, c.SendStatus(StatusOK)
+		}
+	}
+
+	// A strict-routing miss caused only by the trailing slash is handed to
+	// the configured handler instead of falling through to the plain 404
+	if handler := app.config.TrailingSlashHandler; handler != nil && app.config.StrictRouting &&
+		!c.matched && app.trailingSlashMatch(c) {
+		return true, handler(c)
 	}
 
 	// If c.Next() does not match, return 404
@@ -153,50 +526,261 @@ func (app *App) next(c *Ctx) (bool, error) {
 		// If no match, scan stack again if other methods match the request
 		// Moved from app.handler because middleware may break the route chain
 		err = ErrMethodNotAllowed
+	} else if app.fallbackHandler != nil {
+		// No route under any method matched, give the fallback a chance
+		// before returning the default 404
+		return false, app.fallbackHandler(c)
 	}
 	return false, err
 }
 
+// terminalRouteSkipsMiddleware reports whether the first non-middleware
+// route matching the request path is marked via SkipMiddleware. Predicates
+// and query or attribute constraints are not consulted, the decision is
+// made on method and path alone.
+func terminalRouteSkipsMiddleware(c *Ctx, tree []*Route) bool {
+	for _, route := range tree {
+		if route.use || route.mount {
+			continue
+		}
+		if atomic.LoadInt32(&route.disabled) != 0 {
+			continue
+		}
+		if route.match(c.detectionPath, c.path, &c.values) {
+			return route.skipMiddleware
+		}
+	}
+	return false
+}
+
+// runRoute executes the handler chain of an already matched route on c
+func (app *App) runRoute(c *Ctx, route *Route) error {
+	c.indexHandler = 0
+	if route.compiledChain != nil {
+		return route.compiledChain(c)
+	}
+	if len(route.Handlers) > 0 {
+		// A previous compiled chain may have left its continuation
+		// behind, e.g. after RestartRouting
+		c.compiledNext = nil
+		return c.runHandler(route.Handlers[0])
+	}
+	return nil
+}
+
 func (app *App) handler(rctx *fasthttp.RequestCtx) { //revive:disable-line:confusing-naming // Having both a Handler() (uppercase) and a handler() (lowercase) is fine. TODO: Use nolint:revive directive instead. See https://github.com/golangci/golangci-lint/issues/3476
 	// Acquire Ctx with fasthttp request from pool
 	c := app.AcquireCtx(rctx)
 	defer app.ReleaseCtx(c)
 
+	// Per-route access logging needs the request duration, only measured
+	// when log formats are configured at all
+	var start time.Time
+	if len(app.config.LogFormats) > 0 {
+		start = time.Now()
+	}
+
 	// handle invalid http method directly
 	if c.methodINT == -1 {
 		_ = c.Status(StatusBadRequest).SendString("Invalid http method") //nolint:errcheck // It is fine to ignore the error here
 		return
 	}
 
+	// Maintenance mode bypasses routing for everything but allowlisted paths
+	if mode, ok := app.maintenance.Load().(*maintenanceMode); ok && mode != nil {
+		if _, allowed := mode.allowlist[c.detectionPath]; !allowed {
+			if err := mode.handler(c); err != nil {
+				if catch := c.app.ErrorHandler(c, err); catch != nil {
+					_ = c.SendStatus(StatusInternalServerError) //nolint:errcheck // It is fine to ignore the error here
+				}
+			}
+			return
+		}
+	}
+
+	// when body streaming is enabled fasthttp hands oversized bodies to the
+	// handler as a stream instead of rejecting them, so enforce BodyLimit
+	// as a hard cap before the handler runs
+	if app.config.StreamRequestBody && app.config.BodyLimit > 0 && c.fasthttp.Request.Header.ContentLength() > app.config.BodyLimit {
+		if catch := c.app.ErrorHandler(c, ErrRequestEntityTooLarge); catch != nil {
+			_ = c.SendStatus(StatusInternalServerError) //nolint:errcheck // It is fine to ignore the error here
+		}
+		return
+	}
+
+	// Rewrite rules run before any matching work, a redirect-mode rule
+	// answers the request directly
+	if len(app.rewrites) > 0 {
+		if handled := app.applyRewrites(c); handled {
+			return
+		}
+	}
+
+	// a pathologically deep path is rejected before any matching work is
+	// done, every '/' starts a segment
+	if limit := app.config.MaxPathSegments; limit > 0 && strings.Count(c.detectionPath, "/") > limit {
+		if catch := c.app.ErrorHandler(c, ErrRequestURITooLong); catch != nil {
+			_ = c.SendStatus(StatusInternalServerError) //nolint:errcheck // It is fine to ignore the error here
+		}
+		return
+	}
+
+	// Pre-route hooks run last before matching, typically to resolve the
+	// request attributes that routes constrain on via Attr
+	for _, hook := range app.onPreRoute {
+		hook(c)
+	}
+
 	// Find match in stack
-	match, err := app.next(c)
+	var match bool
+	var err error
+	if app.config.RecoverHandler != nil {
+		match, err = app.nextWithRecover(c)
+	} else {
+		match, err = app.next(c)
+	}
 	if err != nil {
+		// Reporters subscribed via OnError see the error before it is
+		// rewritten or answered
+		app.notifyError(c, err)
+		// Only errors deliberately wrapping the sentinel count as a
+		// disconnect here; a handler error that merely wraps io.EOF, e.g.
+		// from a truncated upstream read, must reach the error handler
+		if errors.Is(err, ErrClientDisconnected) {
+			if app.config.SkipErrorHandlerOnDisconnect {
+				// the client is gone, nobody reads the response
+				return
+			}
+			err = ErrClientDisconnected
+		}
 		if catch := c.app.ErrorHandler(c, err); catch != nil {
-			_ = c.SendStatus(StatusInternalServerError) //nolint:errcheck // It is fine to ignore the error here
+			// Only force a 500 if nothing was written yet, overriding an
+			// already committed response would corrupt it
+			if c.responseCommitted() {
+				log.Errorf("handler: failed to call ErrorHandler on committed response: %v", catch)
+			} else {
+				_ = c.SendStatus(StatusInternalServerError) //nolint:errcheck // It is fine to ignore the error here
+			}
 		}
 		// TODO: Do we need to return here?
 	}
+	// An untouched response left behind by a clean handler chain is
+	// rewritten according to the configured mode
+	if mode := app.config.EmptyResponseMode; mode != EmptyResponseDefault && match && err == nil &&
+		c.fasthttp.Response.StatusCode() == StatusOK &&
+		len(c.fasthttp.Response.Body()) == 0 && !c.fasthttp.Response.IsBodyStream() {
+		switch mode {
+		case EmptyResponseNoContent:
+			c.Status(StatusNoContent)
+		case EmptyResponseError:
+			if catch := c.app.ErrorHandler(c, NewError(StatusInternalServerError, "handler wrote no response")); catch != nil {
+				_ = c.SendStatus(StatusInternalServerError) //nolint:errcheck // It is fine to ignore the error here
+			}
+		}
+	}
+	// Apply the route's Cache-Control directive unless the handler errored,
+	// set its own directive or produced an error status code
+	if match && err == nil && c.route != nil && c.route.cacheControl != "" {
+		if len(c.fasthttp.Response.Header.Peek(HeaderCacheControl)) == 0 && c.fasthttp.Response.StatusCode() < StatusBadRequest {
+			c.fasthttp.Response.Header.Set(HeaderCacheControl, c.route.cacheControl)
+		}
+	}
+	// Emit Link headers for the route's declared relations, resolving the
+	// related named routes with the current request's parameters
+	if match && err == nil && c.route != nil && len(c.route.related) > 0 {
+		params := Map{}
+		for key, val := range c.AllParams() {
+			params[key] = val
+		}
+		for _, relation := range c.route.related {
+			location, locErr := c.getLocationFromRoute(app.GetRoute(relation.routeName), params)
+			if locErr != nil || location == "" {
+				continue
+			}
+			c.Append(HeaderLink, "<"+location+`>; rel="`+relation.rel+`"`)
+		}
+	}
 	// Generate ETag if enabled
 	if match && app.config.ETag {
 		setETag(c, false)
 	}
+	// Drop buffered responses that crossed the body cap before anything was
+	// sent, stream bodies are capped by the reader SendStream installs
+	if limit := app.config.MaxResponseBodySize; limit > 0 && !c.fasthttp.Response.IsBodyStream() {
+		if size := len(c.fasthttp.Response.Body()); size > limit {
+			log.Errorf("handler: response body size %d exceeds MaxResponseBodySize %d for %s %s", size, limit, c.method, c.pathOriginal)
+			c.fasthttp.Response.Reset()
+			_ = c.SendStatus(StatusInternalServerError) //nolint:errcheck // It is fine to ignore the error here
+		}
+	}
+	// Render the route's access log line last, with the response in its
+	// final state
+	if match && c.route != nil && c.route.logFormat != "" {
+		if format, ok := app.config.LogFormats[c.route.logFormat]; ok {
+			app.writeRouteLog(c, format, time.Since(start))
+		}
+	}
+}
+
+// writeRouteLog renders a LogFormats template for a finished request and
+// writes it to the configured log writer, see LogAs.
+func (app *App) writeRouteLog(c *Ctx, format string, duration time.Duration) {
+	line := strings.ReplaceAll(format, "%method%", c.method)
+	line = strings.ReplaceAll(line, "%path%", c.path)
+	line = strings.ReplaceAll(line, "%route%", c.route.Path)
+	line = strings.ReplaceAll(line, "%status%", strconv.Itoa(c.fasthttp.Response.StatusCode()))
+	line = strings.ReplaceAll(line, "%duration%", duration.String())
+	writer := app.config.LogWriter
+	if writer == nil {
+		writer = os.Stdout
+	}
+	if _, err := writer.Write([]byte(line + "\n")); err != nil {
+		log.Errorf("writeRouteLog: %v", err)
+	}
 }
 
-func (app *App) addPrefixToRoute(prefix string, route *Route) *Route {
+// nextWithRecover runs app.next and converts a handler panic into an error
+// via the configured RecoverHandler. The deferred ReleaseCtx in app.handler
+// still runs either way, so a recovered panic never leaks the pooled Ctx.
+func (app *App) nextWithRecover(c *Ctx) (match bool, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = app.config.RecoverHandler(c, recovered)
+		}
+	}()
+	match, err = app.next(c)
+	return
+}
+
+func (app *App) addPrefixToRoute(prefix string, route *Route, origin ...*App) *Route {
+	// Routes of mounted sub-apps adopt the parent's routing semantics by
+	// default, the originating app's config wins when it asked to preserve
+	// its own CaseSensitive/StrictRouting settings
+	cfg := app.config
+	if len(origin) > 0 && origin[0].config.PreserveRoutingConfigOnMount {
+		cfg = origin[0].config
+		route.caseSensitive = cfg.CaseSensitive
+		route.strictRouting = cfg.StrictRouting
+	}
+
 	prefixedPath := getGroupPath(prefix, route.Path)
 	prettyPath := prefixedPath
 	// Case-sensitive routing, all to lowercase
-	if !app.config.CaseSensitive {
+	if !cfg.CaseSensitive {
 		prettyPath = utils.ToLower(prettyPath)
 	}
 	// Strict routing, remove trailing slashes
-	if !app.config.StrictRouting && len(prettyPath) > 1 {
+	if !cfg.StrictRouting && len(prettyPath) > 1 {
 		prettyPath = utils.TrimRight(prettyPath, '/')
 	}
 
 	route.Path = prefixedPath
 	route.path = RemoveEscapeChar(prettyPath)
-	route.routeParser = parseRoute(prettyPath)
+	route.routeParser = app.parsePattern(prettyPath)
+	// The prefix may introduce params of its own, e.g. mounting under
+	// "/v:version", so the param list must be rebuilt from the full path -
+	// match only attempts param matching when Params is non-empty
+	route.Params = app.parsePattern(prefixedPath).params
 	route.root = false
 	route.star = false
 
@@ -215,8 +799,59 @@ func (*App) copyRoute(route *Route) *Route {
 		path:        route.path,
 		routeParser: route.routeParser,
 
+		// Response data
+		cacheControl: route.cacheControl,
+
+		// Rate-limit data
+		rateLimitMax:    route.rateLimitMax,
+		rateLimitWindow: route.rateLimitWindow,
+
+		// Connection deadline overrides
+		readTimeout:  route.readTimeout,
+		writeTimeout: route.writeTimeout,
+
+		// Request coalescing
+		singleFlightKey: route.singleFlightKey,
+
+		// Middleware opt-out
+		skipMiddleware: route.skipMiddleware,
+
+		// Scheme restriction
+		scheme: route.scheme,
+
+		// Accepted content types
+		consumes: route.consumes,
+
+		// Compression opt-out
+		compressionDisabled: route.compressionDisabled,
+
+		// Match predicate
+		predicate: route.predicate,
+
+		// Query constraints
+		queryConstraints: route.queryConstraints,
+
+		// Attribute constraints
+		attrConstraints: route.attrConstraints,
+
+		// Middleware prefix semantics
+		loosePrefixMatch: route.loosePrefixMatch,
+		pinned:           route.pinned,
+
+		// Protocol upgrades
+		allowsUpgrade: route.allowsUpgrade,
+
+		// Link header relations
+		related: route.related,
+
+		// Preserved routing semantics
+		caseSensitive: route.caseSensitive,
+		strictRouting: route.strictRouting,
+
 		// misc
-		pos: route.pos,
+		pos:           route.pos,
+		compiledChain: route.compiledChain,
+		logFormat:     route.logFormat,
 
 		// Public data
 		Path:     route.Path,
@@ -227,36 +862,112 @@ func (*App) copyRoute(route *Route) *Route {
 	}
 }
 
-func (app *App) register(method, pathRaw string, group *Group, handlers ...Handler) {
-	// Uppercase HTTP methods
-	method = utils.ToUpper(method)
+// validateRoute runs the registration-time checks shared by register and
+// ValidateRoute: method validity, non-empty and non-nil handlers and clean
+// path characters. The path is expected to be normalized already.
+func (app *App) validateRoute(method, pathRaw string, handlers []Handler, isMount bool) error {
 	// Check if the HTTP method is valid unless it's USE
 	if method != methodUse && app.methodInt(method) == -1 {
-		panic(fmt.Sprintf("add: invalid http method %s\n", method))
+		return fmt.Errorf("add: invalid http method %s", method)
 	}
-	// is mounted app
-	isMount := group != nil && group.app != app
 	// A route requires atleast one ctx handler
 	if len(handlers) == 0 && !isMount {
-		panic(fmt.Sprintf("missing handler in route: %s\n", pathRaw))
+		return fmt.Errorf("missing handler in route: %s", pathRaw)
+	}
+	// Cannot have a nil handler, fail at registration instead of at request time
+	for i, h := range handlers {
+		if h == nil {
+			return fmt.Errorf("nil handler in route: %s, handler index: %d", pathRaw, i)
+		}
+	}
+	// Guard against invisible registration mistakes like control characters
+	if pos := invalidPathCharPosition(pathRaw, app.config.EnableStrictPathValidation); pos != -1 {
+		return fmt.Errorf("route: invalid character %q at position %d in path: %s", pathRaw[pos], pos, pathRaw)
+	}
+	return nil
+}
+
+// ValidateRoute runs the same validation register applies, plus the path
+// parser, without mutating the route table. This lets tools lint a proposed
+// route table in CI without side effects. Malformed patterns that the parser
+// reports by panicking are converted into errors.
+func (app *App) ValidateRoute(method, path string, handlers ...Handler) (err error) {
+	method = utils.ToUpper(method)
+	// Normalize the path the same way register does
+	if path == "" {
+		path = "/"
 	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
+	if err = app.validateRoute(method, path, handlers, false); err != nil {
+		return err
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("route: %v", recovered)
+		}
+	}()
+	app.parsePattern(path)
+	return nil
+}
+
+func (app *App) register(method, pathRaw string, group *Group, handlers ...Handler) {
+	app.registerWithOptions(method, pathRaw, group, registerOptions{}, handlers...)
+}
+
+// registerOptions tweaks how registerWithOptions normalizes a route.
+type registerOptions struct {
+	// exactPath skips the CaseSensitive/StrictRouting path normalization,
+	// the route matches the request path byte for byte
+	exactPath bool
+	// exactMethod skips the method uppercasing, the method must then be
+	// listed in Config.RequestMethods in the same case
+	exactMethod bool
+	// pinned marks middleware whose position is rebased to the front after
+	// registration (UseFirst), such routes never merge with their neighbors
+	pinned bool
+}
+
+// registerWithOptions is the registration funnel behind register, AddExact
+// and AddExactMethod.
+func (app *App) registerWithOptions(method, pathRaw string, group *Group, opts registerOptions, handlers ...Handler) {
+	// Uppercase HTTP methods
+	if !opts.exactMethod {
+		method = utils.ToUpper(method)
+	}
+	// is mounted app
+	isMount := group != nil && group.app != app
 	// Cannot have an empty path
 	if pathRaw == "" {
+		// strict builds treat an empty path as a programming error
+		if app.config.RejectEmptyPath {
+			panic(fmt.Sprintf("route: empty path registered for method %s, see RejectEmptyPath\n", method))
+		}
 		pathRaw = "/"
 	}
 	// Path always start with a '/'
 	if pathRaw[0] != '/' {
 		pathRaw = "/" + pathRaw
 	}
+	if err := app.validateRoute(method, pathRaw, handlers, isMount); err != nil {
+		panic(fmt.Sprintf("%v\n", err))
+	}
 	// Create a stripped path in-case sensitive / trailing slashes
 	pathPretty := pathRaw
-	// Case-sensitive routing, all to lowercase
-	if !app.config.CaseSensitive {
-		pathPretty = utils.ToLower(pathPretty)
-	}
-	// Strict routing, remove trailing slashes
-	if !app.config.StrictRouting && len(pathPretty) > 1 {
-		pathPretty = utils.TrimRight(pathPretty, '/')
+	if !opts.exactPath {
+		// Case-sensitive routing, all to lowercase
+		if !app.config.CaseSensitive {
+			pathPretty = utils.ToLower(pathPretty)
+		}
+		// Strict routing, remove trailing slashes
+		strict := app.config.StrictRouting
+		if app.config.StrictForMutatingMethods && method != methodUse && !isSafeMethod(method) {
+			strict = true
+		}
+		if !strict && len(pathPretty) > 1 {
+			pathPretty = utils.TrimRight(pathPretty, '/')
+		}
 	}
 	// Is layer a middleware?
 	isUse := method == methodUse
@@ -265,16 +976,17 @@ func (app *App) register(method, pathRaw string, group *Group, handlers ...Handl
 	// Is path a root slash?
 	isRoot := pathPretty == "/"
 	// Parse path parameters
-	parsedRaw := parseRoute(pathRaw)
-	parsedPretty := parseRoute(pathPretty)
+	parsedRaw := app.parsePattern(pathRaw)
+	parsedPretty := app.parsePattern(pathPretty)
 
 	// Create route metadata without pointer
 	route := Route{
 		// Router booleans
-		use:   isUse,
-		mount: isMount,
-		star:  isStar,
-		root:  isRoot,
+		use:    isUse,
+		mount:  isMount,
+		star:   isStar,
+		root:   isRoot,
+		pinned: opts.pinned,
 
 		// Path data
 		path:        RemoveEscapeChar(pathPretty),
@@ -289,6 +1001,12 @@ func (app *App) register(method, pathRaw string, group *Group, handlers ...Handl
 		Method:   method,
 		Handlers: handlers,
 	}
+	// Exact routes match case and trailing slashes regardless of config
+	if opts.exactPath {
+		route.caseSensitive = true
+		route.strictRouting = true
+	}
+	route.loosePrefixMatch = app.config.EnableLooseMiddlewarePrefix
 	// Increment global handler count
 	atomic.AddUint32(&app.handlersCount, uint32(len(handlers)))
 
@@ -454,10 +1172,29 @@ func (app *App) addRoute(method string, route *Route, isMounted ...bool) {
 	// Get unique HTTP method identifier
 	m := app.methodInt(method)
 
-	// prevent identically route registration
+	// prevent identically route registration, except when the previous route
+	// is guarded by a predicate, query constraints or accepted content
+	// types - then both must stay distinct candidates
 	l := len(app.stack[m])
-	if l > 0 && app.stack[m][l-1].Path == route.Path && route.use == app.stack[m][l-1].use && !route.mount && !app.stack[m][l-1].mount {
+	if l > 0 && app.stack[m][l-1].Path == route.Path && route.use == app.stack[m][l-1].use &&
+		!route.mount && !app.stack[m][l-1].mount &&
+		!route.pinned && !app.stack[m][l-1].pinned &&
+		app.stack[m][l-1].predicate == nil && len(app.stack[m][l-1].queryConstraints) == 0 &&
+		len(app.stack[m][l-1].attrConstraints) == 0 && len(app.stack[m][l-1].consumes) == 0 &&
+		app.stack[m][l-1].scheme == "" {
 		preRoute := app.stack[m][l-1]
+		// register guards against empty handler chains, so an empty merge
+		// can only come from an internal caller like copyRoute or buildTree
+		// handing over a broken route - surface that loudly instead of
+		// silently keeping the previous route's handlers only
+		if len(route.Handlers) == 0 {
+			panic(fmt.Sprintf("route: internal error, merging empty handler chain into %s %s\n", method, route.Path))
+		}
+		// An ever-growing handler chain on one path is usually a
+		// registration loop gone wrong, fail loudly when a limit is set
+		if limit := app.config.MaxHandlersPerRoute; limit > 0 && len(preRoute.Handlers)+len(route.Handlers) > limit {
+			panic(fmt.Sprintf("route: %s exceeds MaxHandlersPerRoute (%d)\n", route.Path, limit))
+		}
 		preRoute.Handlers = append(preRoute.Handlers, route.Handlers...)
 	} else {
 		// Increment global route position
@@ -466,6 +1203,18 @@ func (app *App) addRoute(method string, route *Route, isMounted ...bool) {
 		// Add route to the stack
 		app.stack[m] = append(app.stack[m], route)
 		app.routesRefreshed = true
+		app.notifyRouteAdd(route)
+	}
+
+	// Routes registered on a mounted sub-app after its parent already pulled
+	// the sub-app's routes are propagated to the parent's stack here, so a
+	// mount never freezes the sub-app's route table
+	if parent := app.mountFields.parent; parent != nil && parent.mountFields.subAppsRoutesPulled && !route.mount {
+		for _, prefix := range app.mountFields.parentPrefixes {
+			clone := parent.copyRoute(route)
+			parent.addPrefixToRoute(prefix, clone, app)
+			parent.addRoute(method, clone, true)
+		}
 	}
 
 	// Execute onRoute hooks & change latestRoute if not adding mounted route
@@ -479,12 +1228,48 @@ func (app *App) addRoute(method string, route *Route, isMounted ...bool) {
 	}
 }
 
-// buildTree build the prefix tree from the previously registered routes
+// compileChain folds a handler chain into nested closures built once at tree
+// build time. Each layer knows its successor and its own index up front, so a
+// request hop through Next swaps a single function pointer instead of
+// incrementing and bounds-checking the handler index. The index bookkeeping
+// visible through HandlerIndex is preserved by constant assignments.
+func compileChain(handlers []Handler) Handler {
+	// the tail continuation leaves the route and resumes scanning the stack
+	next := func(c *Ctx) error {
+		c.indexHandler = len(handlers)
+		c.compiledNext = nil
+		_, err := c.app.next(c)
+		return err
+	}
+	for i := len(handlers) - 1; i >= 0; i-- {
+		index, handler, successor := i, handlers[i], next
+		next = func(c *Ctx) error {
+			c.indexHandler = index
+			c.compiledNext = successor
+			return c.runHandler(handler)
+		}
+	}
+	return next
+}
+
+// getTreeStack returns the tree stack requests currently match against. The
+// stack is swapped whole on rebuild, so callers always see a consistent tree.
+func (app *App) getTreeStack() []map[string][]*Route {
+	treeStack, _ := app.treeStack.Load().([]map[string][]*Route) //nolint:errcheck // the zero value is an empty tree
+	return treeStack
+}
+
+// buildTree build the prefix tree from the previously registered routes.
+// The new tree is assembled off to the side and swapped in with a single
+// assignment, so requests served concurrently keep reading a consistent
+// tree, either the old one or the new one.
 func (app *App) buildTree() *App {
 	if !app.routesRefreshed {
 		return app
 	}
 
+	treeStack := make([]map[string][]*Route, len(app.config.RequestMethods))
+
 	// loop all the methods and stacks and create the prefix tree
 	for m := range app.config.RequestMethods {
 		tsMap := make(map[string][]*Route)
@@ -492,16 +1277,22 @@ func (app *App) buildTree() *App {
 			treePath := ""
 			if len(route.routeParser.segs) > 0 && len(route.routeParser.segs[0].Const) >= 3 {
 				treePath = route.routeParser.segs[0].Const[:3]
+				// the request-side bucket key is derived from the lowered
+				// detection path, so exact routes with uppercase letters
+				// must be bucketed case-insensitively as well
+				if !app.config.CaseSensitive {
+					treePath = utils.ToLower(treePath)
+				}
 			}
 			// create tree stack
 			tsMap[treePath] = append(tsMap[treePath], route)
 		}
-		app.treeStack[m] = tsMap
+		treeStack[m] = tsMap
 	}
 
 	// loop the methods and tree stacks and add global stack and sort everything
 	for m := range app.config.RequestMethods {
-		tsMap := app.treeStack[m]
+		tsMap := treeStack[m]
 		for treePart := range tsMap {
 			if treePart != "" {
 				// merge global tree routes in current tree stack
@@ -510,9 +1301,108 @@ func (app *App) buildTree() *App {
 			// sort tree slices with the positions
 			slc := tsMap[treePart]
 			sort.Slice(slc, func(i, j int) bool { return slc[i].pos < slc[j].pos })
+			app.finishTreeBucket(slc)
+		}
+	}
+
+	// Opt-in: fold each route's handler chain into one composed function.
+	// Chains are recompiled on every rebuild so handlers merged into a
+	// route since the last build are picked up
+	if app.config.EnableCompiledHandlerChains {
+		for m := range app.config.RequestMethods {
+			for _, route := range app.stack[m] {
+				if len(route.Handlers) > 0 {
+					route.compiledChain = compileChain(route.Handlers)
+				}
+			}
 		}
 	}
+
+	// swap in the finished tree
+	app.treeStack.Store(treeStack)
 	app.routesRefreshed = false
 
 	return app
 }
+
+// finishTreeBucket applies the secondary ordering passes every tree bucket
+// goes through after the position sort, shared between the full rebuild and
+// the incremental insert so both produce identical buckets
+func (app *App) finishTreeBucket(slc []*Route) {
+	sortByQuerySpecificity(slc)
+	// Opt-in: wildcard routes are tried last, so more specific
+	// routes win regardless of registration order
+	if app.config.EnableWildcardDemotion {
+		sort.SliceStable(slc, func(i, j int) bool {
+			demotedI := slc[i].star && !slc[i].use
+			demotedJ := slc[j].star && !slc[j].use
+			return !demotedI && demotedJ
+		})
+	}
+}
+
+// insertRouteIntoTree adds a single just-registered terminal route to the
+// live tree without a full rebuild, see AddDynamic. The affected method map
+// and buckets are copied, the route is slotted in via binary search on its
+// position and the finished tree is swapped in atomically, so concurrent
+// requests keep matching against a consistent tree throughout.
+func (app *App) insertRouteIntoTree(route *Route) {
+	old := app.getTreeStack()
+	treeStack := make([]map[string][]*Route, len(old))
+	copy(treeStack, old)
+
+	m := app.methodInt(route.Method)
+	if m == -1 {
+		return
+	}
+	treePath := ""
+	if len(route.routeParser.segs) > 0 && len(route.routeParser.segs[0].Const) >= 3 {
+		treePath = route.routeParser.segs[0].Const[:3]
+		if !app.config.CaseSensitive {
+			treePath = utils.ToLower(treePath)
+		}
+	}
+
+	tsMap := make(map[string][]*Route, len(old[m])+1)
+	for k, v := range old[m] {
+		tsMap[k] = v
+	}
+	if treePath == "" {
+		// catch-all routes participate in every bucket of the method
+		for k := range tsMap {
+			tsMap[k] = app.insertRouteSorted(tsMap[k], route)
+		}
+		if _, ok := tsMap[""]; !ok {
+			tsMap[""] = app.insertRouteSorted(nil, route)
+		}
+	} else {
+		bucket, ok := tsMap[treePath]
+		if !ok {
+			// a fresh bucket starts out with the catch-all routes
+			bucket = tsMap[""]
+		}
+		tsMap[treePath] = app.insertRouteSorted(bucket, route)
+	}
+	treeStack[m] = tsMap
+
+	if app.config.EnableCompiledHandlerChains && len(route.Handlers) > 0 {
+		route.compiledChain = compileChain(route.Handlers)
+	}
+
+	// swap in the updated tree
+	app.treeStack.Store(treeStack)
+}
+
+// insertRouteSorted returns a copy of the bucket with the route slotted into
+// its position-sorted place, the original slice stays untouched for readers
+// of the previous tree
+func (app *App) insertRouteSorted(bucket []*Route, route *Route) []*Route {
+	i := sort.Search(len(bucket), func(j int) bool { return bucket[j].pos > route.pos })
+	slc := make([]*Route, 0, len(bucket)+1)
+	slc = append(slc, bucket[:i]...)
+	slc = append(slc, route)
+	slc = append(slc, bucket[i:]...)
+	app.finishTreeBucket(slc)
+
+	return slc
+}
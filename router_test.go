@@ -12,6 +12,8 @@ import (
 	"io"
 	"net/http/httptest"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -209,6 +211,41 @@ func Test_Route_Match_UnescapedPath(t *testing.T) {
 	utils.AssertEqual(t, StatusNotFound, resp.StatusCode, "Status code")
 }
 
+func Test_Route_Match_UnescapeDetectionPath(t *testing.T) {
+	t.Parallel()
+
+	app := New(Config{UnescapeDetectionPath: true})
+
+	app.Get("/files/report.pdf", func(c *Ctx) error {
+		return c.SendString(c.Path())
+	})
+
+	// encoded unreserved characters match their literal form
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/files/report%2Epdf", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// the user-facing path keeps the escape
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, "/files/report%2Epdf", app.getString(body))
+
+	// reserved escapes like %2F stay raw and never become separators
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/files%2Freport.pdf", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode, "Status code")
+
+	// check deactivated behavior, escaped and literal forms stay distinct
+	app.config.UnescapeDetectionPath = false
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/files/report%2Epdf", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode, "Status code")
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/files/report.pdf", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
 func Test_Route_Match_WithEscapeChar(t *testing.T) {
 	t.Parallel()
 
@@ -623,6 +660,81 @@ func Benchmark_Router_Chain(b *testing.B) {
 	}
 }
 
+// go test -run Test_Router_CompiledHandlerChain
+func Test_Router_CompiledHandlerChain(t *testing.T) {
+	t.Parallel()
+	app := New(Config{EnableCompiledHandlerChains: true})
+
+	var order []string
+	mw := func(name string) Handler {
+		return func(c *Ctx) error {
+			order = append(order, fmt.Sprintf("%s:%d", name, c.HandlerIndex()))
+			return c.Next()
+		}
+	}
+	app.Use(mw("use"))
+	app.Get("/compiled", mw("a"), mw("b"), func(c *Ctx) error {
+		order = append(order, fmt.Sprintf("final:%d", c.HandlerIndex()))
+		return c.SendStatus(StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/compiled", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	// the chain crosses the middleware route into the GET route, indexes
+	// reported by HandlerIndex restart per route like in iterated mode
+	utils.AssertEqual(t, "use:0,a:0,b:1,final:2", strings.Join(order, ","))
+
+	// a miss still falls through the compiled middleware chain to the 404
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/missing", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode, "Status code")
+}
+
+// go test -v ./... -run=^$ -bench=Benchmark_Router_Chain_Compiled -benchmem -count=4
+func Benchmark_Router_Chain_Compiled(b *testing.B) {
+	app := New(Config{EnableCompiledHandlerChains: true})
+	handler := func(c *Ctx) error {
+		return c.Next()
+	}
+	app.Get("/", handler, handler, handler, handler, handler, func(c *Ctx) error {
+		return nil
+	})
+
+	appHandler := app.Handler()
+
+	c := &fasthttp.RequestCtx{}
+
+	c.Request.Header.SetMethod(MethodGet)
+	c.URI().SetPath("/")
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		appHandler(c)
+	}
+}
+
+// go test -v ./... -run=^$ -bench=Benchmark_Router_Chain_Iterated -benchmem -count=4
+func Benchmark_Router_Chain_Iterated(b *testing.B) {
+	app := New()
+	handler := func(c *Ctx) error {
+		return c.Next()
+	}
+	app.Get("/", handler, handler, handler, handler, handler, func(c *Ctx) error {
+		return nil
+	})
+
+	appHandler := app.Handler()
+
+	c := &fasthttp.RequestCtx{}
+
+	c.Request.Header.SetMethod(MethodGet)
+	c.URI().SetPath("/")
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		appHandler(c)
+	}
+}
+
 // go test -v ./... -run=^$ -bench=Benchmark_Router_WithCompression -benchmem -count=4
 func Benchmark_Router_WithCompression(b *testing.B) {
 	app := New()
@@ -868,3 +980,1148 @@ type routeJSON struct {
 	TestRoutes []testRoute `json:"test_routes"`
 	GithubAPI  []testRoute `json:"github_api"`
 }
+
+func Test_Route_CacheControl(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Get("/config", func(c *Ctx) error {
+		return c.SendString("config")
+	}).CacheControl("public, max-age=300")
+	app.Get("/custom", func(c *Ctx) error {
+		c.Set(HeaderCacheControl, "no-store")
+		return c.SendString("custom")
+	}).CacheControl("public, max-age=300")
+	app.Get("/error", func(_ *Ctx) error {
+		return ErrTeapot
+	}).CacheControl("public, max-age=300")
+
+	// directive is applied on success
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/config", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, "public, max-age=300", resp.Header.Get(HeaderCacheControl))
+
+	// handler-set header wins
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/custom", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, "no-store", resp.Header.Get(HeaderCacheControl))
+
+	// errors are not cached
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/error", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusTeapot, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, "", resp.Header.Get(HeaderCacheControl))
+}
+
+// go test -run Test_Route_Related
+func Test_Route_Related(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Get("/page/:nr", func(c *Ctx) error {
+		return c.SendString("page " + c.Params("nr"))
+	}).Related("next", "page.next").Related("prev", "page.prev").Related("canonical", "missing")
+	app.Get("/page/:nr/next", func(c *Ctx) error {
+		return c.SendString("next")
+	}).Name("page.next")
+	app.Get("/page/:nr/prev", func(c *Ctx) error {
+		return c.SendString("prev")
+	}).Name("page.prev")
+	app.Get("/error", func(_ *Ctx) error {
+		return ErrTeapot
+	}).Related("next", "page.next")
+
+	// relations resolve with the request's params, unknown names are skipped
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/page/2", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, `</page/2/next>; rel="next", </page/2/prev>; rel="prev"`, resp.Header.Get(HeaderLink))
+
+	// errored handlers emit no Link header
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/error", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusTeapot, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, "", resp.Header.Get(HeaderLink))
+}
+
+// go test -run Test_Route_RejectEmptyPath
+// go test -run Test_Router_WildcardDemotion
+func Test_Router_WildcardDemotion(t *testing.T) {
+	t.Parallel()
+
+	setup := func(config ...Config) *App {
+		app := New(config...)
+		app.Get("/*", func(c *Ctx) error {
+			return c.SendString("wildcard")
+		})
+		app.Get("/specific", func(c *Ctx) error {
+			return c.SendString("specific")
+		})
+		return app
+	}
+	body := func(t *testing.T, app *App, path string) string {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(MethodGet, path, nil))
+		utils.AssertEqual(t, nil, err, "app.Test(req)")
+		b, err := io.ReadAll(resp.Body)
+		utils.AssertEqual(t, nil, err)
+		return string(b)
+	}
+
+	// by default the wildcard wins through registration order
+	utils.AssertEqual(t, "wildcard", body(t, setup(), "/specific"))
+
+	// demoted, the specific route wins while everything else still
+	// falls through to the wildcard
+	app := setup(Config{EnableWildcardDemotion: true})
+	utils.AssertEqual(t, "specific", body(t, app, "/specific"))
+	utils.AssertEqual(t, "wildcard", body(t, app, "/anything/else"))
+}
+
+// go test -run Test_Route_SkipMiddleware
+func Test_Route_SkipMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var middlewareRan bool
+	app := New()
+	app.Use(func(c *Ctx) error {
+		middlewareRan = true
+		return c.Next()
+	})
+	app.Get("/healthz", func(c *Ctx) error {
+		return c.SendString("ok")
+	}).SkipMiddleware()
+	app.Get("/normal", func(c *Ctx) error {
+		return c.SendString("normal")
+	})
+
+	// the skip-marked route runs without the global middleware
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/healthz", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, false, middlewareRan)
+
+	// normal routes still pass through it
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/normal", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, true, middlewareRan)
+}
+
+// go test -run Test_App_InsertRouteIntoTree
+func Test_App_InsertRouteIntoTree(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Use("/api", testEmptyHandler)
+	app.Get("/api/users", testEmptyHandler)
+	app.Get("/api/users/:id", testEmptyHandler)
+	app.Post("/files/*", testEmptyHandler)
+	app.Get("/x", testEmptyHandler)
+	app.startupProcess()
+
+	// snapshot renders the tree deterministically for comparison
+	snapshot := func() string {
+		var sb strings.Builder
+		treeStack := app.getTreeStack()
+		for m := range treeStack {
+			buckets := make([]string, 0, len(treeStack[m]))
+			for key := range treeStack[m] {
+				buckets = append(buckets, key)
+			}
+			sort.Strings(buckets)
+			for _, key := range buckets {
+				fmt.Fprintf(&sb, "%d[%s]:", m, key)
+				for _, route := range treeStack[m][key] {
+					fmt.Fprintf(&sb, " %s %s (%d)", route.Method, route.Path, route.pos)
+				}
+				sb.WriteString("\n")
+			}
+		}
+		return sb.String()
+	}
+
+	// insert into an existing bucket, a fresh bucket and the catch-all one
+	app.AddDynamic(MethodGet, "/api/orders", testEmptyHandler)
+	app.AddDynamic(MethodGet, "/newbucket/route", testEmptyHandler)
+	app.AddDynamic(MethodGet, "/y", testEmptyHandler)
+
+	// the routes are matchable right away, without a tree rebuild
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Request.Header.SetMethod(MethodGet)
+	fctx.URI().SetPath("/newbucket/route")
+	app.Handler()(fctx)
+	utils.AssertEqual(t, StatusOK, fctx.Response.StatusCode())
+
+	// the incrementally grown tree matches a full rebuild exactly
+	incremental := snapshot()
+	app.mutex.Lock()
+	app.routesRefreshed = true
+	app.buildTree()
+	app.mutex.Unlock()
+	utils.AssertEqual(t, snapshot(), incremental)
+}
+
+func Benchmark_App_TreeInsert_Incremental(b *testing.B) {
+	app := New()
+	for i := 0; i < 5000; i++ {
+		app.Get("/bench/route/"+strconv.Itoa(i), testEmptyHandler)
+	}
+	app.startupProcess()
+	app.Get("/bench/added", testEmptyHandler)
+	route := app.latestRoute
+	base := app.getTreeStack()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		app.treeStack.Store(base)
+		app.insertRouteIntoTree(route)
+	}
+}
+
+func Benchmark_App_TreeInsert_FullRebuild(b *testing.B) {
+	app := New()
+	for i := 0; i < 5000; i++ {
+		app.Get("/bench/route/"+strconv.Itoa(i), testEmptyHandler)
+	}
+	app.startupProcess()
+	app.Get("/bench/added", testEmptyHandler)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		app.routesRefreshed = true
+		app.buildTree()
+	}
+}
+
+func Test_Route_RejectEmptyPath(t *testing.T) {
+	t.Parallel()
+
+	// lenient default registers "/"
+	app := New()
+	app.Get("", testEmptyHandler)
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// strict mode panics
+	strict := New(Config{RejectEmptyPath: true})
+	defer func() {
+		if err := recover(); err != nil {
+			utils.AssertEqual(t, "route: empty path registered for method HEAD, see RejectEmptyPath\n", fmt.Sprintf("%v", err))
+			return
+		}
+		t.Fatal("expected register to panic on empty path")
+	}()
+	strict.Get("", testEmptyHandler)
+}
+
+func Test_Route_Registration_Nil_Handler(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+
+	defer func() {
+		if err := recover(); err != nil {
+			utils.AssertEqual(t, "nil handler in route: /nil, handler index: 1\n", fmt.Sprintf("%v", err))
+			return
+		}
+		t.Fatal("expected register to panic on nil handler")
+	}()
+
+	app.Get("/nil", func(c *Ctx) error { return c.Next() }, nil)
+}
+
+func Test_Router_StrictForMutatingMethods(t *testing.T) {
+	t.Parallel()
+
+	app := New(Config{StrictForMutatingMethods: true})
+	app.Get("/page", func(c *Ctx) error {
+		return c.SendString("page")
+	})
+	app.Post("/submit", func(c *Ctx) error {
+		return c.SendString("submitted")
+	})
+
+	// safe methods keep the lenient trailing-slash behavior
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/page/", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// mutating methods are matched strictly
+	resp, err = app.Test(httptest.NewRequest(MethodPost, "/submit", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	resp, err = app.Test(httptest.NewRequest(MethodPost, "/submit/", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode, "Status code")
+}
+
+func Test_Router_CollapseSlashes(t *testing.T) {
+	t.Parallel()
+
+	app := New(Config{CollapseSlashes: true})
+	app.Get("/api/users", func(c *Ctx) error {
+		return c.SendString("users")
+	})
+	app.Get("/files/*", func(c *Ctx) error {
+		return c.SendString(c.Params("*"))
+	})
+
+	// duplicate slashes are collapsed before matching
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/api//users", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// the wildcard tail is captured in its collapsed form
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/files//a//b", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "a/b", string(body))
+
+	// without the flag double slashes do not match
+	strict := New()
+	strict.Get("/api/users", func(c *Ctx) error {
+		return c.SendString("users")
+	})
+	resp, err = strict.Test(httptest.NewRequest(MethodGet, "/api//users", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode, "Status code")
+}
+
+func Test_Route_Match_BraceParams(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+
+	app.Get("/users/{id}", func(c *Ctx) error {
+		return c.SendString(c.Params("id"))
+	})
+	app.Get("/mixed/:version/files/{name}", func(c *Ctx) error {
+		return c.SendString(c.Params("version") + " " + c.Params("name"))
+	})
+
+	// the bare names are recorded regardless of the syntax
+	utils.AssertEqual(t, []string{"id"}, parseRoute("/users/{id}").params)
+	utils.AssertEqual(t, []string{"version", "name"}, parseRoute("/mixed/:version/files/{name}").params)
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/users/42", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "42", string(body))
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/mixed/v1/files/readme", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "v1 readme", string(body))
+}
+
+func Test_Route_Registration_Invalid_Path_Chars(t *testing.T) {
+	t.Parallel()
+
+	// control characters are always rejected
+	func() {
+		defer func() {
+			err := recover()
+			utils.AssertEqual(t, true, err != nil, "expected register to panic on control char")
+			utils.AssertEqual(t, true, strings.Contains(fmt.Sprintf("%v", err), "position 4"))
+		}()
+		New().Get("/bad\x01path", testEmptyHandler)
+	}()
+
+	// spaces are only rejected in strict mode
+	New().Get("/with space", testEmptyHandler)
+	func() {
+		defer func() {
+			err := recover()
+			utils.AssertEqual(t, true, err != nil, "expected register to panic on space")
+			utils.AssertEqual(t, true, strings.Contains(fmt.Sprintf("%v", err), "position 5"))
+		}()
+		New(Config{EnableStrictPathValidation: true}).Get("/with space", testEmptyHandler)
+	}()
+
+	// intentionally escaped characters stay allowed
+	New(Config{EnableStrictPathValidation: true}).Get("/escaped\\:colon", testEmptyHandler)
+}
+
+func Test_Route_Scheme(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/secure", func(c *Ctx) error {
+		return c.SendString("secure")
+	}).Scheme("https")
+
+	// over https the route matches
+	req := httptest.NewRequest(MethodGet, "/secure", nil)
+	req.Header.Set(HeaderXForwardedProto, schemeHTTPS)
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// over plain http the request is redirected to the https URL
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/secure", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusMovedPermanently, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, "https://example.com/secure", resp.Header.Get(HeaderLocation))
+}
+
+func Test_Route_Scheme_Strict404(t *testing.T) {
+	t.Parallel()
+	app := New(Config{DisableSchemeRedirect: true})
+	app.Get("/secure", func(c *Ctx) error {
+		return c.SendString("secure")
+	}).Scheme("https")
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/secure", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode, "Status code")
+}
+
+func Test_Route_Scheme_Dispatch(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/s", func(c *Ctx) error {
+		return c.SendString("secure")
+	}).Scheme("https")
+	app.Get("/s", func(c *Ctx) error {
+		return c.SendString("plain")
+	}).Scheme("http")
+
+	// the plain request reaches the http alternative, no redirect
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/s", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "plain", string(body))
+
+	// the https request reaches the https alternative
+	req := httptest.NewRequest(MethodGet, "/s", nil)
+	req.Header.Set(HeaderXForwardedProto, schemeHTTPS)
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "secure", string(body))
+}
+
+func Test_Route_Scheme_Invalid(t *testing.T) {
+	t.Parallel()
+	app := New()
+	defer func() {
+		utils.AssertEqual(t, false, recover() == nil)
+	}()
+
+	app.Get("/x", testEmptyHandler).Scheme("gopher")
+}
+
+func Test_Router_MatchTrace_NotFound(t *testing.T) {
+	t.Parallel()
+
+	var trace *MatchTrace
+	app := New(Config{
+		EnableMatchTrace: true,
+		ErrorHandler: func(c *Ctx, err error) error {
+			trace = c.MatchTrace()
+			return DefaultErrorHandler(c, err)
+		},
+	})
+	app.Get("/:id", testEmptyHandler)
+	app.Get("/a", testEmptyHandler)
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/missing/x", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode, "Status code")
+
+	utils.AssertEqual(t, false, trace == nil)
+	utils.AssertEqual(t, "", trace.Bucket)
+	utils.AssertEqual(t, 2, len(trace.Entries))
+	utils.AssertEqual(t, MethodGet, trace.Entries[0].Method)
+	utils.AssertEqual(t, "/:id", trace.Entries[0].Path)
+	utils.AssertEqual(t, false, trace.Entries[0].Matched)
+	utils.AssertEqual(t, "param pattern mismatch", trace.Entries[0].Reason)
+	utils.AssertEqual(t, "/a", trace.Entries[1].Path)
+	utils.AssertEqual(t, "exact path mismatch", trace.Entries[1].Reason)
+}
+
+func Test_Router_MatchTrace_Matched(t *testing.T) {
+	t.Parallel()
+
+	app := New(Config{EnableMatchTrace: true})
+	app.Get("/a", func(c *Ctx) error {
+		trace := c.MatchTrace()
+		utils.AssertEqual(t, false, trace == nil)
+		utils.AssertEqual(t, 1, len(trace.Entries))
+		utils.AssertEqual(t, true, trace.Entries[0].Matched)
+		utils.AssertEqual(t, "matched", trace.Entries[0].Reason)
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/a", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
+func Test_Router_MatchTrace_Disabled(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Get("/a", func(c *Ctx) error {
+		utils.AssertEqual(t, true, c.MatchTrace() == nil)
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/a", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
+func Test_Route_Consumes(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Post("/json", func(c *Ctx) error {
+		return c.SendString("ok")
+	}).Consumes(MIMEApplicationJSON, "text/*")
+
+	// matching content type passes
+	req := httptest.NewRequest(MethodPost, "/json", strings.NewReader(`{}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// wildcard subtype passes
+	req = httptest.NewRequest(MethodPost, "/json", strings.NewReader("x"))
+	req.Header.Set(HeaderContentType, MIMETextPlain)
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+
+	// mismatching content type is rejected before the handler runs
+	req = httptest.NewRequest(MethodPost, "/json", strings.NewReader("<x/>"))
+	req.Header.Set(HeaderContentType, MIMEApplicationXML)
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusUnsupportedMediaType, resp.StatusCode, "Status code")
+
+	// no body and no content type passes by default
+	resp, err = app.Test(httptest.NewRequest(MethodPost, "/json", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+}
+
+func Test_Route_Consumes_RequireContentType(t *testing.T) {
+	t.Parallel()
+	app := New(Config{ConsumesRequireContentType: true})
+	app.Post("/json", testEmptyHandler).Consumes(MIMEApplicationJSON)
+
+	resp, err := app.Test(httptest.NewRequest(MethodPost, "/json", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusUnsupportedMediaType, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_Route_Consumes_Dispatch
+func Test_Route_Consumes_Dispatch(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Post("/upload", func(c *Ctx) error {
+		return c.SendString("json")
+	}).Consumes(MIMEApplicationJSON)
+	app.Post("/upload", func(c *Ctx) error {
+		return c.SendString("multipart")
+	}).Consumes(MIMEMultipartForm)
+
+	request := func(contentType string) (int, string) {
+		req := httptest.NewRequest(MethodPost, "/upload", strings.NewReader("payload"))
+		req.Header.Set(HeaderContentType, contentType)
+		resp, err := app.Test(req)
+		utils.AssertEqual(t, nil, err, "app.Test(req)")
+		body, err := io.ReadAll(resp.Body)
+		utils.AssertEqual(t, nil, err)
+		return resp.StatusCode, string(body)
+	}
+
+	// the request's content type picks the route
+	status, body := request(MIMEApplicationJSON)
+	utils.AssertEqual(t, StatusOK, status, "Status code")
+	utils.AssertEqual(t, "json", body)
+
+	status, body = request(MIMEMultipartForm)
+	utils.AssertEqual(t, StatusOK, status, "Status code")
+	utils.AssertEqual(t, "multipart", body)
+
+	// a body no candidate accepts is rejected, not treated as a 404
+	status, _ = request(MIMEApplicationXML)
+	utils.AssertEqual(t, StatusUnsupportedMediaType, status, "Status code")
+}
+
+func Test_Route_DetectionPath(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/User", testEmptyHandler)
+
+	route := app.GetRoutes(true)[0]
+	utils.AssertEqual(t, "/User", route.Path)
+	utils.AssertEqual(t, "/user", route.DetectionPath())
+
+	// a case-sensitive app keeps the registered casing
+	appSensitive := New(Config{CaseSensitive: true})
+	appSensitive.Get("/User", testEmptyHandler)
+	utils.AssertEqual(t, "/User", appSensitive.GetRoutes(true)[0].DetectionPath())
+}
+
+func Test_App_ValidateRoute(t *testing.T) {
+	t.Parallel()
+	app := New()
+	before := app.routesCount
+
+	utils.AssertEqual(t, nil, app.ValidateRoute(MethodGet, "/users/:id", testEmptyHandler))
+	utils.AssertEqual(t, nil, app.ValidateRoute("get", "users", testEmptyHandler))
+
+	// invalid method
+	err := app.ValidateRoute("SPY", "/x", testEmptyHandler)
+	utils.AssertEqual(t, false, err == nil)
+
+	// missing handler
+	err = app.ValidateRoute(MethodGet, "/x")
+	utils.AssertEqual(t, false, err == nil)
+
+	// nil handler
+	err = app.ValidateRoute(MethodGet, "/x", nil)
+	utils.AssertEqual(t, false, err == nil)
+
+	// nothing was registered along the way
+	utils.AssertEqual(t, before, app.routesCount)
+	utils.AssertEqual(t, 0, len(app.GetRoutes()))
+}
+
+func Test_Route_MaxHandlersPerRoute(t *testing.T) {
+	t.Parallel()
+	app := New(Config{MaxHandlersPerRoute: 3})
+	app.Get("/x", testEmptyHandler, testEmptyHandler)
+	// merging another handler below the limit is fine
+	app.Get("/x", testEmptyHandler)
+
+	defer func() {
+		utils.AssertEqual(t, false, recover() == nil)
+	}()
+
+	// crossing the limit on the merged chain panics, naming the path
+	app.Get("/x", testEmptyHandler)
+}
+
+// go test -run Test_Route_DisableCompression
+func Test_Route_DisableCompression(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	// Minimal compression middleware that honors the per-route opt-out.
+	app.Use(func(c *Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Route().CompressionDisabled() {
+			return nil
+		}
+		c.Set(HeaderContentEncoding, "gzip")
+		return nil
+	})
+
+	app.Get("/img/*", func(c *Ctx) error {
+		return c.SendString("raw bytes")
+	}).DisableCompression()
+
+	app.Get("/page", func(c *Ctx) error {
+		return c.SendString("hello page")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/img/logo.png", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "", resp.Header.Get(HeaderContentEncoding))
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/page", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "gzip", resp.Header.Get(HeaderContentEncoding))
+}
+
+// go test -run Test_Route_Validate
+func Test_Route_Validate(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	type createUserRequest struct {
+		Name string `json:"name" validate:"required"`
+		Age  int    `json:"age"`
+	}
+
+	app.Post("/users", func(c *Ctx) error {
+		return c.SendString("created")
+	}).Validate(&createUserRequest{})
+
+	req := httptest.NewRequest(MethodPost, "/users", strings.NewReader(`{"name":"john","age":30}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	req = httptest.NewRequest(MethodPost, "/users", strings.NewReader(`{"age":30}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusBadRequest, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, `{"errors":[{"field":"name","message":"is required"}]}`, string(body))
+}
+
+// go test -run Test_Route_Validate_InvalidSchema
+func Test_Route_Validate_InvalidSchema(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	defer func() {
+		utils.AssertEqual(t, false, nil == recover(), "should panic on non-struct schema")
+	}()
+	app.Post("/users", testEmptyHandler).Validate("not a struct")
+}
+
+// go test -run Test_Route_When
+func Test_Route_When(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.Get("/x", func(c *Ctx) error {
+		return c.SendString("beta")
+	}).When(func(c *Ctx) bool {
+		return c.Get("X-Beta") == "1"
+	})
+
+	app.Get("/x", func(c *Ctx) error {
+		return c.SendString("stable")
+	})
+
+	req := httptest.NewRequest(MethodGet, "/x", nil)
+	req.Header.Set("X-Beta", "1")
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "beta", string(body))
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/x", nil))
+	utils.AssertEqual(t, nil, err)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "stable", string(body))
+}
+
+// go test -run Test_Route_When_TwoPredicates
+func Test_Route_When_TwoPredicates(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.Get("/x", func(c *Ctx) error {
+		return c.SendString("beta")
+	}).When(func(c *Ctx) bool {
+		return c.Get("X-Beta") == "1"
+	})
+	app.Get("/x", func(c *Ctx) error {
+		return c.SendString("alpha")
+	}).When(func(c *Ctx) bool {
+		return c.Get("X-Alpha") == "1"
+	})
+
+	// each predicate keeps guarding its own route, the second .When must
+	// not overwrite the first route's predicate
+	request := func(header string) string {
+		req := httptest.NewRequest(MethodGet, "/x", nil)
+		if header != "" {
+			req.Header.Set(header, "1")
+		}
+		resp, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+		body, err := io.ReadAll(resp.Body)
+		utils.AssertEqual(t, nil, err)
+		return string(body)
+	}
+	utils.AssertEqual(t, "beta", request("X-Beta"))
+	utils.AssertEqual(t, "alpha", request("X-Alpha"))
+
+	// neither predicate approving still falls through to the 404
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/x", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+}
+
+// go test -run Test_Route_When_NoFallback
+func Test_Route_When_NoFallback(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.Get("/gated", func(c *Ctx) error {
+		return c.SendString("gated")
+	}).When(func(c *Ctx) bool {
+		return false
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/gated", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+}
+
+// go test -run Test_App_AddExact
+func Test_App_AddExact(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.AddExact(MethodGet, "/foo", func(c *Ctx) error {
+		return c.SendString("collection")
+	})
+	app.AddExact(MethodGet, "/foo/", func(c *Ctx) error {
+		return c.SendString("resource")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/foo", nil))
+	utils.AssertEqual(t, nil, err)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "collection", string(body))
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/foo/", nil))
+	utils.AssertEqual(t, nil, err)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "resource", string(body))
+}
+
+// go test -run Test_App_AddExact_CasePreserved
+func Test_App_AddExact_CasePreserved(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.AddExact(MethodGet, "/Foo", func(c *Ctx) error {
+		return c.SendString("exact case")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/Foo", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/foo", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+}
+
+// go test -run Test_App_AddExactMethod
+func Test_App_AddExactMethod(t *testing.T) {
+	t.Parallel()
+	methods := make([]string, len(DefaultMethods), len(DefaultMethods)+1)
+	copy(methods, DefaultMethods)
+	app := New(Config{RequestMethods: append(methods, "purge")})
+
+	app.AddExactMethod("purge", "/cache", func(c *Ctx) error {
+		return c.SendString("purged")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("purge", "/cache", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	// the uppercase variant is a different, unknown method
+	resp, err = app.Test(httptest.NewRequest("PURGE", "/cache", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusBadRequest, resp.StatusCode)
+}
+
+// go test -run Test_App_AddExactMethod_Unknown
+func Test_App_AddExactMethod_Unknown(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	defer func() {
+		utils.AssertEqual(t, false, nil == recover(), "should panic on a method missing from RequestMethods")
+	}()
+	app.AddExactMethod("purge", "/cache", testEmptyHandler)
+}
+
+// go test -run Test_App_AddRouteRef
+func Test_App_AddRouteRef(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	route := app.AddRouteRef(MethodGet, "/users/:id/books/:title", testEmptyHandler)
+	utils.AssertEqual(t, false, route == nil)
+	utils.AssertEqual(t, MethodGet, route.Method)
+	utils.AssertEqual(t, "/users/:id/books/:title", route.Path)
+	utils.AssertEqual(t, []string{"id", "title"}, route.Params)
+
+	// the handle points into the live route table
+	route.Name = "books.show"
+	utils.AssertEqual(t, "/users/:id/books/:title", app.GetRoute("books.show").Path)
+}
+
+// go test -run Test_Route_Query
+func Test_Route_Query(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	// the fallback is registered first on purpose: specificity, not
+	// registration order, decides among routes sharing a path
+	app.Get("/search", func(c *Ctx) error {
+		return c.SendString("default")
+	})
+	app.Get("/search", func(c *Ctx) error {
+		return c.SendString("images")
+	}).Query("type", "image")
+	app.Get("/search", func(c *Ctx) error {
+		return c.SendString("videos")
+	}).Query("type", "video")
+
+	cases := map[string]string{
+		"/search?type=image":       "images",
+		"/search?type=video":       "videos",
+		"/search?type=application": "default",
+		"/search":                  "default",
+	}
+	for target, expected := range cases {
+		resp, err := app.Test(httptest.NewRequest(MethodGet, target, nil))
+		utils.AssertEqual(t, nil, err)
+		body, err := io.ReadAll(resp.Body)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, expected, string(body), target)
+	}
+}
+
+// go test -run Test_Route_Attr
+func Test_Route_Attr(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.OnPreRoute(func(c *Ctx) {
+		c.SetAttr("tenant", c.Get("X-Tenant"))
+	})
+
+	// unlike Query there is no specificity sorting, the constrained route
+	// must be registered before the fallback
+	app.Get("/x", func(c *Ctx) error {
+		return c.SendString("premium")
+	}).Attr("tenant", "premium")
+	app.Get("/x", func(c *Ctx) error {
+		return c.SendString("default")
+	})
+
+	cases := map[string]string{
+		"premium": "premium",
+		"free":    "default",
+		"":        "default",
+	}
+	for tenant, expected := range cases {
+		req := httptest.NewRequest(MethodGet, "/x", nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant", tenant)
+		}
+		resp, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+		body, err := io.ReadAll(resp.Body)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, expected, string(body), tenant)
+	}
+}
+
+// go test -run Test_Route_LogAs
+func Test_Route_LogAs(t *testing.T) {
+	t.Parallel()
+	buf := new(strings.Builder)
+	app := New(Config{
+		LogFormats: map[string]string{
+			"access": "%method% %path% -> %status% via %route%",
+		},
+		LogWriter: buf,
+	})
+	app.Get("/users/:id", func(c *Ctx) error {
+		return c.SendStatus(StatusOK)
+	}).LogAs("access")
+	app.Get("/silent", testEmptyHandler)
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/users/42", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, "GET /users/42 -> 200 via /users/:id\n", buf.String())
+
+	// a route without LogAs emits nothing
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/silent", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, "GET /users/42 -> 200 via /users/:id\n", buf.String())
+}
+
+// go test -run Test_Route_Query_MultipleConstraints
+func Test_Route_Query_MultipleConstraints(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.Get("/filter", func(c *Ctx) error {
+		return c.SendString("broad")
+	}).Query("type", "image")
+	app.Get("/filter", func(c *Ctx) error {
+		return c.SendString("narrow")
+	}).Query("type", "image").Query("size", "large")
+
+	// both constraint sets hold, the more specific route wins
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/filter?type=image&size=large", nil))
+	utils.AssertEqual(t, nil, err)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "narrow", string(body))
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/filter?type=image", nil))
+	utils.AssertEqual(t, nil, err)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "broad", string(body))
+
+	// nothing satisfied and no fallback registered
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/filter", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+}
+
+// go test -run Test_App_addRoute_EmptyHandlersGuard
+func Test_App_addRoute_EmptyHandlersGuard(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/degenerate", testEmptyHandler)
+
+	defer func() {
+		utils.AssertEqual(t, false, nil == recover(), "should panic when an empty handler chain is merged")
+	}()
+	// bypass register's guard the way a broken internal copy would
+	app.addRoute(MethodGet, &Route{Path: "/degenerate", path: "/degenerate", Method: MethodGet})
+}
+
+// go test -run Test_Route_Use_PrefixBoundary
+func Test_Route_Use_PrefixBoundary(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	var hits int
+	app.Use("/api", func(c *Ctx) error {
+		hits++
+		return c.Next()
+	})
+	app.Get("/api/x", testEmptyHandler)
+	app.Get("/apiary", testEmptyHandler)
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/api/x", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, 1, hits)
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/apiary", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, 1, hits, "middleware must not run for /apiary")
+}
+
+// go test -run Test_Route_Use_PrefixBoundary_Loose
+func Test_Route_Use_PrefixBoundary_Loose(t *testing.T) {
+	t.Parallel()
+	app := New(Config{EnableLooseMiddlewarePrefix: true})
+
+	var hits int
+	app.Use("/api", func(c *Ctx) error {
+		hits++
+		return c.Next()
+	})
+	app.Get("/apiary", testEmptyHandler)
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/apiary", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, 1, hits, "loose mode keeps the old prefix semantics")
+}
+
+// go test -run Test_App_SetRouteEnabled
+func Test_App_SetRouteEnabled(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	app.Get("/feature", func(c *Ctx) error {
+		return c.SendString("on")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/feature", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	app.SetRouteEnabled(MethodGet, "/feature", false)
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/feature", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode)
+
+	app.SetRouteEnabled(MethodGet, "/feature", true)
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/feature", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+}
+
+// go test -run Test_Route_Segments
+func Test_Route_Segments(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Get("/a/:b/*", testEmptyHandler)
+
+	segments := app.GetRoutes()[0].Segments()
+	// the parser emits the separating "/" as its own constant segment
+	utils.AssertEqual(t, 4, len(segments))
+
+	utils.AssertEqual(t, "/a/", segments[0].Const)
+	utils.AssertEqual(t, false, segments[0].IsParam)
+
+	utils.AssertEqual(t, "b", segments[1].Param)
+	utils.AssertEqual(t, true, segments[1].IsParam)
+	utils.AssertEqual(t, false, segments[1].IsGreedy)
+
+	utils.AssertEqual(t, "/", segments[2].Const)
+
+	utils.AssertEqual(t, "*1", segments[3].Param)
+	utils.AssertEqual(t, true, segments[3].IsParam)
+	utils.AssertEqual(t, true, segments[3].IsGreedy)
+	utils.AssertEqual(t, true, segments[3].IsOptional)
+	utils.AssertEqual(t, true, segments[3].IsLast)
+}
+
+// go test -run Test_App_AddRouteIfAbsent
+func Test_App_AddRouteIfAbsent(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	utils.AssertEqual(t, true, app.AddRouteIfAbsent(MethodGet, "/plugin", testEmptyHandler))
+	utils.AssertEqual(t, false, app.AddRouteIfAbsent(MethodGet, "/plugin", testEmptyHandler))
+
+	// case-insensitive routing treats the variants as the same route
+	utils.AssertEqual(t, false, app.AddRouteIfAbsent(MethodGet, "/Plugin/", testEmptyHandler))
+
+	// the second call did not extend the handler chain
+	var route Route
+	for _, r := range app.GetRoutes() {
+		if r.Method == MethodGet && r.Path == "/plugin" {
+			route = r
+		}
+	}
+	utils.AssertEqual(t, 1, len(route.Handlers))
+
+	// a different method on the same path is absent and registers fine
+	utils.AssertEqual(t, true, app.AddRouteIfAbsent(MethodPost, "/plugin", testEmptyHandler))
+}
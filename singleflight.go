@@ -0,0 +1,120 @@
+// ⚡️ Fiber is an Express inspired web framework written in Go with ☕️
+// 🤖 Github Repository: https://github.com/gofiber/fiber
+// 📌 API Documentation: https://docs.gofiber.io
+
+package fiber
+
+import (
+	"sync"
+
+	"github.com/valyala/fasthttp"
+)
+
+// flightCall is one in-flight handler execution shared by coalesced requests
+type flightCall struct {
+	done chan struct{}      // closed once the leader finished
+	resp *fasthttp.Response // buffered leader response, nil when err is set
+	err  error
+}
+
+// flightGroup tracks the in-flight handler executions by coalescing key
+type flightGroup struct {
+	mutex sync.Mutex
+	calls map[string]*flightCall
+}
+
+// SingleFlight coalesces concurrent identical requests to the latest
+// registered route into a single handler execution. keyFn computes the
+// coalescing key per request; requests arriving while an execution for the
+// same key is in flight wait for it and receive a copy of its buffered
+// response instead of running the handlers themselves:
+//
+//	app.Get("/expensive", handler).SingleFlight(func(c *Ctx) string {
+//		return c.Path() + "?" + c.Query("page")
+//	})
+//
+// An empty key opts the request out of coalescing. When the shared
+// execution returns an error every waiter receives it, each passing through
+// the error handler on its own. Only sensible for idempotent handlers whose
+// response does not depend on per-request state beyond the key.
+func (app *App) SingleFlight(keyFn func(*Ctx) string) Router {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	// A repeated registration on the same path is merged into the existing
+	// route's handler chain; carve it back out first, coalescing must not
+	// apply to the routes it was merged with
+	if !app.latestRoute.use {
+		app.splitMergedRoute()
+	}
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			// Like Query, coalescing must not leak onto earlier routes
+			// sharing the path. Only the route itself, its paired HEAD
+			// route and per-method copies of a middleware are touched.
+			isPairedHead := app.latestRoute.Method == MethodGet && route.Method == MethodHead &&
+				route.Path == app.latestRoute.Path && route.pos+1 == app.latestRoute.pos
+			isUseCopy := app.latestRoute.use && route.use && route.Path == app.latestRoute.Path
+
+			if route == app.latestRoute || isPairedHead || isUseCopy {
+				route.singleFlightKey = keyFn
+			}
+		}
+	}
+
+	return app
+}
+
+// SingleFlight coalesces concurrent identical requests to the latest
+// registered route into a single handler execution.
+func (grp *Group) SingleFlight(keyFn func(*Ctx) string) Router {
+	grp.app.SingleFlight(keyFn)
+
+	return grp
+}
+
+// singleFlightDo runs the route's handlers as the leader for the given key,
+// or waits for the in-flight leader and copies its buffered response. The
+// leader releases its waiters even when the handlers panic; the panic
+// itself keeps propagating on the leader's request only.
+func (app *App) singleFlightDo(c *Ctx, route *Route, key string) error {
+	app.flights.mutex.Lock()
+	if call, ok := app.flights.calls[key]; ok {
+		app.flights.mutex.Unlock()
+		<-call.done
+		if call.err != nil {
+			return call.err
+		}
+		call.resp.CopyTo(&c.fasthttp.Response)
+		return nil
+	}
+	if app.flights.calls == nil {
+		app.flights.calls = make(map[string]*flightCall)
+	}
+	call := &flightCall{done: make(chan struct{})}
+	app.flights.calls[key] = call
+	app.flights.mutex.Unlock()
+
+	completed := false
+	defer func() {
+		if !completed {
+			call.err = NewError(StatusInternalServerError, "single flight handler panicked")
+		}
+		app.flights.mutex.Lock()
+		delete(app.flights.calls, key)
+		app.flights.mutex.Unlock()
+		close(call.done)
+	}()
+
+	err := app.runRoute(c, route)
+	if err != nil {
+		call.err = err
+	} else {
+		call.resp = &fasthttp.Response{}
+		c.fasthttp.Response.CopyTo(call.resp)
+	}
+	completed = true
+
+	return err
+}
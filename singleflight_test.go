@@ -0,0 +1,89 @@
+// ⚡️ Fiber is an Express inspired web framework written in Go with ☕️
+// 📃 Github Repository: https://github.com/gofiber/fiber
+// 📌 API Documentation: https://docs.gofiber.io
+
+//nolint:bodyclose // Much easier to just ignore memory leaks in tests
+package fiber
+
+import (
+	"io"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// go test -run Test_Route_SingleFlight
+func Test_Route_SingleFlight(t *testing.T) {
+	t.Parallel()
+
+	const concurrency = 10
+
+	var calls, joined int32
+	// entered counts down once per request that computed its key, so the
+	// shared execution cannot finish before every request joined the flight
+	var entered sync.WaitGroup
+	entered.Add(concurrency)
+
+	app := New()
+	app.Get("/expensive", func(c *Ctx) error {
+		atomic.AddInt32(&calls, 1)
+		entered.Wait()
+		time.Sleep(100 * time.Millisecond)
+		return c.SendString("result")
+	}).SingleFlight(func(c *Ctx) string {
+		if atomic.AddInt32(&joined, 1) <= concurrency {
+			entered.Done()
+		}
+		return c.Path()
+	})
+
+	bodies := make(chan string, concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			resp, err := app.Test(httptest.NewRequest(MethodGet, "/expensive", nil), 5000)
+			if err != nil {
+				bodies <- "err: " + err.Error()
+				return
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				bodies <- "err: " + err.Error()
+				return
+			}
+			bodies <- string(body)
+		}()
+	}
+
+	// every request gets the shared response, the handler ran once
+	for i := 0; i < concurrency; i++ {
+		utils.AssertEqual(t, "result", <-bodies)
+	}
+	utils.AssertEqual(t, int32(1), atomic.LoadInt32(&calls))
+
+	// a request after the flight completed triggers a fresh execution
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/expensive", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusOK, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+// go test -run Test_Route_SingleFlight_Error
+func Test_Route_SingleFlight_Error(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Get("/fail", func(c *Ctx) error {
+		return ErrTeapot
+	}).SingleFlight(func(c *Ctx) string {
+		return c.Path()
+	})
+
+	// errors pass through the error handler like an uncoalesced request
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/fail", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusTeapot, resp.StatusCode, "Status code")
+}
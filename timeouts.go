@@ -0,0 +1,121 @@
+// ⚡️ Fiber is an Express inspired web framework written in Go with ☕️
+// 🤖 Github Repository: https://github.com/gofiber/fiber
+// 📌 API Documentation: https://docs.gofiber.io
+
+package fiber
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+)
+
+// Timeouts overrides the connection read and write deadlines for the latest
+// registered route, e.g. an upload endpoint that needs more room than the
+// server-wide Config.ReadTimeout/Config.WriteTimeout allow:
+//
+//	app.Post("/upload", handler).Timeouts(5*time.Minute, time.Minute)
+//
+// A zero duration keeps the server-level value. The overrides cannot be
+// applied from inside the handler chain: fasthttp reads the request body
+// before the handlers run and re-arms the write deadline after they return,
+// so a deadline set on the connection mid-request would come too late or be
+// overwritten. They are therefore resolved as soon as the request line and
+// headers are parsed, by matching them against the registered routes; the
+// first matching route that declares an override wins. Routes must be
+// registered before the app starts serving for the overrides to be seen.
+func (app *App) Timeouts(read, write time.Duration) Router {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	// A repeated registration on the same path is merged into the existing
+	// route's handler chain; carve it back out first, the overrides must
+	// not apply to the routes it was merged with
+	if !app.latestRoute.use {
+		app.splitMergedRoute()
+	}
+
+	for _, routes := range app.stack {
+		for _, route := range routes {
+			// Like Query, the overrides must not leak onto earlier routes
+			// sharing the path. Only the route itself, its paired HEAD
+			// route and per-method copies of a middleware are touched.
+			isPairedHead := app.latestRoute.Method == MethodGet && route.Method == MethodHead &&
+				route.Path == app.latestRoute.Path && route.pos+1 == app.latestRoute.pos
+			isUseCopy := app.latestRoute.use && route.use && route.Path == app.latestRoute.Path
+
+			if route == app.latestRoute || isPairedHead || isUseCopy {
+				route.readTimeout = read
+				route.writeTimeout = write
+			}
+		}
+	}
+
+	// hook into the server now that at least one route carries an override,
+	// apps without per-route timeouts keep the hook disabled entirely
+	app.server.HeaderReceived = app.routeTimeoutConfig
+
+	return app
+}
+
+// Timeouts overrides the connection deadlines for the latest registered route.
+func (grp *Group) Timeouts(read, write time.Duration) Router {
+	grp.app.Timeouts(read, write)
+
+	return grp
+}
+
+// routeTimeoutConfig is wired as the fasthttp HeaderReceived hook once a
+// route declares a deadline override, see Timeouts. It matches the request
+// line against the route tree and returns the deadline overrides of the
+// first matching route that has any; the zero config keeps the server-level
+// timeouts.
+func (app *App) routeTimeoutConfig(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+	var conf fasthttp.RequestConfig
+
+	methodINT := app.methodInt(app.getString(header.Method()))
+	if methodINT == -1 {
+		return conf
+	}
+
+	// normalize the raw request URI the same way the router does
+	path := app.getString(header.RequestURI())
+	if i := strings.IndexByte(path, '?'); i != -1 {
+		path = path[:i]
+	}
+	detectionPath := path
+	if !app.config.CaseSensitive {
+		detectionPath = utils.ToLower(detectionPath)
+	}
+	if !app.config.StrictRouting && len(detectionPath) > 1 && detectionPath[len(detectionPath)-1] == '/' {
+		detectionPath = utils.TrimRight(detectionPath, '/')
+	}
+
+	treePath := ""
+	const maxDetectionPaths = 3
+	if len(detectionPath) >= maxDetectionPaths {
+		treePath = detectionPath[:maxDetectionPaths]
+	}
+	treeStack := app.getTreeStack()
+	tree, ok := treeStack[methodINT][treePath]
+	if !ok {
+		tree = treeStack[methodINT][""]
+	}
+
+	var values [maxParams]string
+	for _, route := range tree {
+		if route.readTimeout == 0 && route.writeTimeout == 0 {
+			continue
+		}
+		if !route.match(detectionPath, path, &values) {
+			continue
+		}
+		conf.ReadTimeout = route.readTimeout
+		conf.WriteTimeout = route.writeTimeout
+		break
+	}
+
+	return conf
+}
@@ -0,0 +1,108 @@
+// ⚡️ Fiber is an Express inspired web framework written in Go with ☕️
+// 📃 Github Repository: https://github.com/gofiber/fiber
+// 📌 API Documentation: https://docs.gofiber.io
+
+package fiber
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+)
+
+// requestTimeoutConfig resolves the deadline overrides for a raw request line
+func requestTimeoutConfig(app *App, method, uri string) fasthttp.RequestConfig {
+	var header fasthttp.RequestHeader
+	header.SetMethod(method)
+	header.SetRequestURI(uri)
+
+	return app.routeTimeoutConfig(&header)
+}
+
+// go test -run Test_Route_Timeouts
+func Test_Route_Timeouts(t *testing.T) {
+	t.Parallel()
+
+	app := New(Config{
+		DisableStartupMessage: true,
+		WriteTimeout:          50 * time.Millisecond,
+	})
+
+	// stream a response in two parts with a pause the server-level write
+	// timeout does not survive
+	slowStream := func(c *Ctx) error {
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			_, _ = w.WriteString("part1")
+			_ = w.Flush()
+			time.Sleep(250 * time.Millisecond)
+			_, _ = w.WriteString("part2")
+		})
+		return nil
+	}
+	app.Get("/default", slowStream)
+	app.Get("/extended", slowStream).Timeouts(0, 2*time.Second)
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() {
+		utils.AssertEqual(t, nil, app.Listener(ln))
+	}()
+	defer func() {
+		utils.AssertEqual(t, nil, app.Shutdown())
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	request := func(path string) string {
+		conn, err := net.Dial(NetworkTCP4, ln.Addr().String())
+		utils.AssertEqual(t, nil, err)
+		defer func() { _ = conn.Close() }()
+
+		_, err = conn.Write([]byte("GET " + path + " HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"))
+		utils.AssertEqual(t, nil, err)
+
+		// a read error just means the cut-off connection was reset
+		body, _ := io.ReadAll(conn)
+		return string(body)
+	}
+
+	// the server-level write timeout cuts the stream short ...
+	resp := request("/default")
+	utils.AssertEqual(t, false, strings.Contains(resp, "part2"), resp)
+
+	// ... while the per-route override lets it complete
+	resp = request("/extended")
+	utils.AssertEqual(t, true, strings.Contains(resp, "part1"), resp)
+	utils.AssertEqual(t, true, strings.Contains(resp, "part2"), resp)
+}
+
+// go test -run Test_App_routeTimeoutConfig
+func Test_App_routeTimeoutConfig(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Get("/files/:name", testEmptyHandler).Timeouts(time.Minute, time.Second)
+	app.Get("/plain", testEmptyHandler)
+	app.startupProcess()
+
+	conf := requestTimeoutConfig(app, MethodGet, "/files/report.pdf?dl=1")
+	utils.AssertEqual(t, time.Minute, conf.ReadTimeout)
+	utils.AssertEqual(t, time.Second, conf.WriteTimeout)
+
+	// routes without an override keep the server-level timeouts
+	conf = requestTimeoutConfig(app, MethodGet, "/plain")
+	utils.AssertEqual(t, time.Duration(0), conf.ReadTimeout)
+	utils.AssertEqual(t, time.Duration(0), conf.WriteTimeout)
+
+	// so do unknown paths and unknown methods
+	conf = requestTimeoutConfig(app, MethodGet, "/missing")
+	utils.AssertEqual(t, time.Duration(0), conf.ReadTimeout)
+	conf = requestTimeoutConfig(app, "FOO", "/files/report.pdf")
+	utils.AssertEqual(t, time.Duration(0), conf.ReadTimeout)
+}